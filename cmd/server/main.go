@@ -1,7 +1,12 @@
 package main
 
 import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"flag"
 	"log"
+	"os"
 	"time"
 
 	"github.com/didip/tollbooth/v7"
@@ -9,44 +14,77 @@ import (
 	tbgin "github.com/didip/tollbooth_gin"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 
+	"github.com/olamideolayemi/framelane-api/internal/auth"
+	"github.com/olamideolayemi/framelane-api/internal/cleanup"
 	"github.com/olamideolayemi/framelane-api/internal/config"
 	"github.com/olamideolayemi/framelane-api/internal/db"
 	"github.com/olamideolayemi/framelane-api/internal/email"
+	"github.com/olamideolayemi/framelane-api/internal/inventory"
 	"github.com/olamideolayemi/framelane-api/internal/models"
+	"github.com/olamideolayemi/framelane-api/internal/pricing"
 	"github.com/olamideolayemi/framelane-api/internal/routes"
+	"github.com/olamideolayemi/framelane-api/internal/scan"
 	"github.com/olamideolayemi/framelane-api/internal/seed"
 	"github.com/olamideolayemi/framelane-api/internal/storage"
+	"github.com/olamideolayemi/framelane-api/internal/thumbs"
 	"github.com/olamideolayemi/framelane-api/ws"
 )
 
 func main() {
 	cfg := config.Load()
+	if len(os.Args) > 1 && os.Args[1] == "seed" {
+		runSeed(cfg, os.Args[2:])
+		return
+	}
+
 	email.Init()
 	d := db.Connect(cfg.DatabaseURL)
-
-	if err := d.AutoMigrate(&models.FrameSize{}, &models.Frame{}); err != nil {
-		log.Fatal("Failed to migrate FrameSize table:", err)
+	migrateCatalog(d)
+	if err := db.BackfillDeliveredAt(d); err != nil {
+		log.Fatal("failed to backfill delivery dates:", err)
 	}
+	go (&pricing.Scheduler{DB: d}).Run(context.Background())
 
-	// Seed frame sizes after DB connection
-	if err := seed.SeedFrameSizes(d); err != nil {
-		log.Fatal("failed to seed frame sizes:", err)
+	store, err := newStore(cfg)
+	if err != nil {
+		log.Fatal("failed to set up storage:", err)
 	}
 
-	s3, err := storage.New(cfg.S3Endpoint, cfg.S3AccessKey, cfg.S3SecretKey, cfg.S3UseSSL, cfg.S3Bucket)
+	keys, err := loadKeys(cfg)
 	if err != nil {
-		log.Fatal(err)
+		log.Fatal("failed to load JWT keys:", err)
+	}
+
+	// Thumbnails are generated in the background after uploads are finalized
+	thumbWorker := thumbs.NewWorker(d, store)
+	thumbWorker.MaxPixels = cfg.ImageMaxPixels
+	go thumbWorker.Run(context.Background(), 2)
+
+	// Unreferenced uploads and expired images are removed on a schedule
+	collector := &cleanup.Collector{
+		DB:         d,
+		Store:      store,
+		Grace:      time.Duration(cfg.UploadGraceH) * time.Hour,
+		Retention:  time.Duration(cfg.RetentionDays) * 24 * time.Hour,
+		Quarantine: time.Duration(cfg.QuarantineDays) * 24 * time.Hour,
+	}
+	if cfg.CleanupIntervalH > 0 {
+		go collector.RunEvery(context.Background(), time.Duration(cfg.CleanupIntervalH)*time.Hour, cfg.CleanupDryRun)
 	}
 
 	mailer := email.New(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUser, cfg.SMTPPass, cfg.FromEmail)
 
 	// Create one router instance
 	r := gin.New()
+	if err := r.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		log.Fatal("invalid TRUSTED_PROXIES:", err)
+	}
 	r.Use(gin.Recovery(), cors.New(cors.Config{
 		AllowOrigins:     []string{"http://framelane-framer-app-v1.2.vercel.app", "http://localhost:3000"},
 		AllowMethods:     []string{"GET", "POST", "PATCH", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Authorization", "Content-Type"},
+		AllowHeaders:     []string{"Authorization", "Content-Type", "X-API-Key"},
 		ExposeHeaders:    []string{"Content-Length"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
@@ -56,10 +94,21 @@ func main() {
 	lim := tollbooth.NewLimiter(10, &limiter.ExpirableOptions{DefaultExpirationTTL: time.Hour})
 	r.Use(tbgin.LimitHandler(lim))
 
+	// Per-account and per-IP login lockout, on top of the global limiter
+	throttle := &auth.Throttle{
+		DB:            d,
+		MaxFailures:   cfg.LoginMaxFailures,
+		MaxIPFailures: cfg.LoginMaxIPFailures,
+		Lockout:       time.Duration(cfg.LoginLockoutMin) * time.Minute,
+		BaseDelay:     time.Second,
+	}
+
+	local, _ := store.(*storage.Local)
+
 	// Register routes
 	routes.Setup(r, routes.Deps{
-		DB: d, JWTSecret: cfg.JWTSecret, JWTHours: cfg.JWTExpiresH,
-		S3: s3, Email: mailer,
+		DB: d, Keys: keys, JWTHours: cfg.JWTExpiresH, TOTPIssuer: cfg.TOTPIssuer,
+		OIDC: oidcProviders(cfg), Throttle: throttle, APIKeys: &auth.APIKeys{DB: d}, Store: store, LocalStore: local, UploadMaxBytes: int64(cfg.UploadMaxMB) << 20, MultipartMaxBytes: int64(cfg.MultipartMaxMB) << 20, ImageMaxPixels: cfg.ImageMaxPixels, Scanner: newScanner(cfg), Thumbs: thumbWorker, Cleanup: collector, Email: mailer, Inventory: &inventory.Service{DB: d, Email: mailer},
 	})
 
 	hub := ws.NewHub()
@@ -74,3 +123,123 @@ func main() {
 		log.Fatal(err)
 	}
 }
+
+// migrateCatalog migrates the catalogue tables and backfills fields added
+// since rows were created.
+func migrateCatalog(d *gorm.DB) {
+	if err := d.AutoMigrate(&models.FrameSize{}, &models.Frame{}, &models.FrameImage{}, &models.OptionGroup{}, &models.OptionChoice{}, &models.StockItem{}, &models.CustomSizeQuote{}, &models.PriceVersion{}, &models.SeedRun{}); err != nil {
+		log.Fatal("Failed to migrate FrameSize table:", err)
+	}
+
+	if err := db.BackfillFrameSizes(d); err != nil {
+		log.Fatal("failed to backfill frame size dimensions:", err)
+	}
+	if err := db.BackfillFrameSlugs(d); err != nil {
+		log.Fatal("failed to backfill frame slugs:", err)
+	}
+	if err := db.BackfillPriceHistory(d); err != nil {
+		log.Fatal("failed to backfill price history:", err)
+	}
+}
+
+// runSeed is the "seed" subcommand: server seed [-file seeds/catalog.yaml] [-force]
+func runSeed(cfg *config.Config, args []string) {
+	fs := flag.NewFlagSet("seed", flag.ExitOnError)
+	file := fs.String("file", seed.DefaultFile, "YAML or JSON catalogue seed file")
+	force := fs.Bool("force", false, "re-apply an applied version and overwrite existing rows")
+	_ = fs.Parse(args)
+
+	f, err := seed.Load(*file)
+	if err != nil {
+		log.Fatal("seed: ", err)
+	}
+	d := db.Connect(cfg.DatabaseURL)
+	migrateCatalog(d)
+
+	res, err := seed.Apply(d, f, *force)
+	if errors.Is(err, seed.ErrApplied) {
+		log.Printf("seed: version %d already applied, use -force to apply it again", f.Version)
+		return
+	}
+	if errors.Is(err, seed.ErrChanged) {
+		log.Fatalf("seed: %s differs from the file version %d was applied from; bump its version, or use -force to apply it as version %d", *file, f.Version, f.Version)
+	}
+	if err != nil {
+		log.Fatal("seed: ", err)
+	}
+	log.Printf("seed: applied version %d: created=%d updated=%d skipped=%d", res.Version, res.Created, res.Updated, res.Skipped)
+}
+
+// newScanner returns the malware scanner uploads go through on finalize.
+func newScanner(cfg *config.Config) scan.Scanner {
+	if cfg.Scanner == "clamav" {
+		return &scan.ClamAV{Addr: cfg.ClamdAddr, Timeout: time.Duration(cfg.ClamdTimeoutS) * time.Second}
+	}
+	return scan.NoOp{}
+}
+
+// newStore picks the object store: S3/MinIO by default, or files on disk with
+// STORAGE_BACKEND=local for running without MinIO.
+func newStore(cfg *config.Config) (storage.Store, error) {
+	ttl := time.Duration(cfg.S3URLTTLMin) * time.Minute
+	if cfg.StorageBackend == "local" {
+		secret := []byte(cfg.LocalStorageSecret)
+		if len(secret) == 0 {
+			log.Println("WARNING: LOCAL_STORAGE_SECRET not set, using a random one; signed URLs will not survive a restart")
+			secret = make([]byte, 32)
+			if _, err := rand.Read(secret); err != nil {
+				return nil, err
+			}
+		}
+		local, err := storage.NewLocal(cfg.LocalStorageDir, cfg.PublicBaseURL, secret)
+		if err != nil {
+			return nil, err
+		}
+		local.ViewTTL = ttl
+		return local, nil
+	}
+
+	s3, err := storage.New(cfg.S3Endpoint, cfg.S3AccessKey, cfg.S3SecretKey, cfg.S3UseSSL, cfg.S3Bucket)
+	if err != nil {
+		return nil, err
+	}
+	s3.ViewTTL = ttl
+	if public, err := s3.PublicReadable(context.Background()); err != nil {
+		log.Printf("could not read bucket policy: %v", err)
+	} else if public {
+		log.Printf("WARNING: bucket %s allows anonymous reads; customer photos are exposed. Remove the public policy.", cfg.S3Bucket)
+	}
+	return s3, nil
+}
+
+func loadKeys(cfg *config.Config) (*auth.KeySet, error) {
+	if cfg.JWTKeysDir == "" {
+		// Every replica and restart would sign with a different key
+		if !cfg.DevMode {
+			return nil, errors.New("JWT_KEYS_DIR is not set; set APP_ENV=development to use a throwaway key")
+		}
+		log.Println("WARNING: JWT_KEYS_DIR not set, using an ephemeral signing key; sessions will not survive a restart")
+		return auth.NewEphemeralKeySet()
+	}
+	legacy := ""
+	if cfg.JWTAcceptLegacy {
+		legacy = cfg.JWTSecret
+	}
+	return auth.LoadKeySet(cfg.JWTKeysDir, cfg.JWTActiveKID, legacy)
+}
+
+// oidcProviders enables each social login provider that has client IDs configured.
+func oidcProviders(cfg *config.Config) map[string]*auth.OIDCProvider {
+	providers := map[string]*auth.OIDCProvider{}
+	if len(cfg.GoogleClientIDs) > 0 {
+		providers["google"] = &auth.OIDCProvider{
+			Name: "google", Issuer: cfg.GoogleIssuer, JWKSURL: cfg.GoogleJWKSURL, ClientIDs: cfg.GoogleClientIDs,
+		}
+	}
+	if len(cfg.AppleClientIDs) > 0 {
+		providers["apple"] = &auth.OIDCProvider{
+			Name: "apple", Issuer: cfg.AppleIssuer, JWKSURL: cfg.AppleJWKSURL, ClientIDs: cfg.AppleClientIDs,
+		}
+	}
+	return providers
+}
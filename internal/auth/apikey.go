@@ -0,0 +1,105 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/olamideolayemi/framelane-api/internal/models"
+)
+
+var ErrInvalidAPIKey = errors.New("invalid api key")
+
+// lastUsedEvery limits how often a busy key writes its last-used time.
+const lastUsedEvery = time.Minute
+
+// APIKeys authenticates X-API-Key headers and enforces each key's rate limit.
+// Rate windows are kept in memory, like the global tollbooth limiter.
+type APIKeys struct {
+	DB *gorm.DB
+
+	mu      sync.Mutex
+	windows map[string]*keyWindow
+}
+
+type keyWindow struct {
+	start time.Time
+	count int
+}
+
+// GenerateAPIKey returns the plaintext key ("fl_<prefix>_<secret>"), its
+// lookup prefix and the hash to store.
+func GenerateAPIKey() (plain, prefix, hash string, err error) {
+	p := make([]byte, 4)
+	s := make([]byte, 32)
+	if _, err = rand.Read(p); err != nil {
+		return
+	}
+	if _, err = rand.Read(s); err != nil {
+		return
+	}
+	prefix = hex.EncodeToString(p)
+	plain = "fl_" + prefix + "_" + base64.RawURLEncoding.EncodeToString(s)
+	return plain, prefix, hashAPIKey(plain), nil
+}
+
+func hashAPIKey(plain string) string {
+	sum := sha256.Sum256([]byte(plain))
+	return hex.EncodeToString(sum[:])
+}
+
+// Authenticate looks up a plaintext key and checks it is still usable and
+// its owner is still active.
+func (a *APIKeys) Authenticate(plain string) (*models.APIKey, error) {
+	parts := strings.SplitN(plain, "_", 3)
+	if len(parts) != 3 || parts[0] != "fl" {
+		return nil, ErrInvalidAPIKey
+	}
+	var k models.APIKey
+	if err := a.DB.Preload("User").Where("prefix = ?", parts[1]).First(&k).Error; err != nil {
+		return nil, ErrInvalidAPIKey
+	}
+	if subtle.ConstantTimeCompare([]byte(k.KeyHash), []byte(hashAPIKey(plain))) != 1 {
+		return nil, ErrInvalidAPIKey
+	}
+	now := time.Now()
+	if !k.Usable(now) {
+		return nil, ErrInvalidAPIKey
+	}
+	// A key acts as its owner, so it stops working when the owner is deactivated
+	if k.User.ID != k.UserID || !k.User.IsActive {
+		return nil, ErrInvalidAPIKey
+	}
+	if k.LastUsedAt == nil || now.Sub(*k.LastUsedAt) > lastUsedEvery {
+		a.DB.Model(&models.APIKey{}).Where("id = ?", k.ID).Update("last_used_at", now)
+	}
+	return &k, nil
+}
+
+// Allow counts one request against the key's per-minute limit.
+func (a *APIKeys) Allow(k *models.APIKey) bool {
+	if k.RateLimit <= 0 {
+		return true
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.windows == nil {
+		a.windows = map[string]*keyWindow{}
+	}
+	now := time.Now()
+	w, ok := a.windows[k.Prefix]
+	if !ok || now.Sub(w.start) >= time.Minute {
+		w = &keyWindow{start: now}
+		a.windows[k.Prefix] = w
+	}
+	w.count++
+	return w.count <= k.RateLimit
+}
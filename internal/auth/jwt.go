@@ -1,26 +1,86 @@
 package auth
 
 import (
+	"slices"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// ScopeTwoFactor marks a challenge token that is only good for completing
+// the second login step.
+const ScopeTwoFactor = "2fa"
+
+// Audiences. Sessions and 2FA challenges are signed with the same keys and
+// published in the JWKS, so services verifying our tokens must require
+// SessionAudience; challenge tokens never carry it.
+const (
+	SessionAudience   = "framelane-api"
+	ChallengeAudience = "framelane-2fa"
+)
+
 type Claims struct {
 	UserID  string `json:"uid"`
-	IsAdmin bool `json:"adm"`
+	IsAdmin bool   `json:"adm"`
+	MFA     bool   `json:"mfa,omitempty"` // session passed a second factor
+	Scope   string `json:"scp,omitempty"` // empty for normal sessions
 	jwt.RegisteredClaims
 }
 
-func MakeToken(secret string, uid string, admin bool, hours int) (string, error) {
+func MakeToken(keys *KeySet, uid string, admin bool, mfa bool, hours int) (string, error) {
 	claims := &Claims{
 		UserID:  uid,
 		IsAdmin: admin,
+		MFA:     mfa,
 		RegisteredClaims: jwt.RegisteredClaims{
+			Audience:  jwt.ClaimStrings{SessionAudience},
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Duration(hours) * time.Hour)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
-	t := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return t.SignedString([]byte(secret))
+	return keys.Sign(claims)
+}
+
+// MakeChallengeToken issues a short-lived token that proves the password step
+// succeeded. RequireAuth rejects it; only the 2FA login step accepts it.
+func MakeChallengeToken(keys *KeySet, uid string, ttl time.Duration) (string, error) {
+	claims := &Claims{
+		UserID: uid,
+		Scope:  ScopeTwoFactor,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Audience:  jwt.ClaimStrings{ChallengeAudience},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+	return keys.Sign(claims)
+}
+
+// ParseChallengeToken validates a token from MakeChallengeToken and returns the user ID.
+func ParseChallengeToken(keys *KeySet, tok string) (string, error) {
+	claims := &Claims{}
+	if err := keys.Parse(tok, claims, jwt.WithAudience(ChallengeAudience)); err != nil {
+		return "", err
+	}
+	if claims.Scope != ScopeTwoFactor {
+		return "", jwt.ErrTokenInvalidClaims
+	}
+	return claims.UserID, nil
+}
+
+// ParseSessionToken validates a session token from MakeToken. Challenge tokens
+// are refused; sessions issued before audiences were added have none and are
+// still accepted until they expire.
+func ParseSessionToken(keys *KeySet, tok string) (*Claims, error) {
+	claims := &Claims{}
+	if err := keys.Parse(tok, claims); err != nil {
+		return nil, err
+	}
+	if claims.Scope != "" || slices.Contains(claims.Audience, ChallengeAudience) {
+		return nil, jwt.ErrTokenInvalidClaims
+	}
+	if len(claims.Audience) > 0 && !slices.Contains(claims.Audience, SessionAudience) {
+		return nil, jwt.ErrTokenInvalidAudience
+	}
+	return claims, nil
 }
@@ -0,0 +1,34 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+func TestChallengeAndSessionTokensDontMix(t *testing.T) {
+	keys, err := NewEphemeralKeySet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	session, err := MakeToken(keys, "u1", false, false, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	challenge, err := MakeChallengeToken(keys, "u1", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if c, err := ParseSessionToken(keys, session); err != nil || c.UserID != "u1" {
+		t.Fatalf("session token refused: %v", err)
+	}
+	if _, err := ParseSessionToken(keys, challenge); err == nil {
+		t.Fatal("challenge token accepted as a session")
+	}
+	if uid, err := ParseChallengeToken(keys, challenge); err != nil || uid != "u1" {
+		t.Fatalf("challenge token refused: %v", err)
+	}
+	if _, err := ParseChallengeToken(keys, session); err == nil {
+		t.Fatal("session token accepted as a challenge")
+	}
+}
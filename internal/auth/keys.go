@@ -0,0 +1,203 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// SigningKey is one entry in the key set. Retired keys keep only the public
+// half so tokens they signed stay valid until they expire.
+type SigningKey struct {
+	ID      string
+	Method  jwt.SigningMethod
+	Private crypto.Signer    // nil for verify-only keys
+	Public  crypto.PublicKey // []byte for the legacy HMAC key
+}
+
+// KeySet signs tokens with the active key and verifies against every
+// configured key, looked up by the token's kid header.
+type KeySet struct {
+	active *SigningKey
+	keys   map[string]*SigningKey
+}
+
+// LegacyKID identifies the old shared-secret HS256 key while it is being phased out.
+const LegacyKID = "legacy-hs256"
+
+// LoadKeySet reads every *.pem file in dir; the file name without extension
+// is the kid. Files may hold a PKCS#8 private key (RSA or Ed25519) or a PKIX
+// public key. activeKID must name a private key. When legacySecret is set,
+// HS256 tokens without a kid are still accepted, never issued.
+func LoadKeySet(dir, activeKID, legacySecret string) (*KeySet, error) {
+	ks := &KeySet{keys: map[string]*SigningKey{}}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range files {
+		raw, err := os.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+		kid := strings.TrimSuffix(filepath.Base(f), ".pem")
+		k, err := parseKeyPEM(kid, raw)
+		if err != nil {
+			return nil, fmt.Errorf("jwt key %s: %w", f, err)
+		}
+		ks.keys[kid] = k
+	}
+
+	if legacySecret != "" {
+		ks.keys[LegacyKID] = &SigningKey{ID: LegacyKID, Method: jwt.SigningMethodHS256, Public: []byte(legacySecret)}
+	}
+
+	active, ok := ks.keys[activeKID]
+	if !ok || active.Private == nil {
+		return nil, fmt.Errorf("active jwt key %q not found or has no private key", activeKID)
+	}
+	ks.active = active
+	return ks, nil
+}
+
+// NewEphemeralKeySet generates a throwaway Ed25519 key. Only meant for local
+// development: every restart invalidates all sessions.
+func NewEphemeralKeySet() (*KeySet, error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	k := &SigningKey{ID: "dev-ephemeral", Method: jwt.SigningMethodEdDSA, Private: priv, Public: pub}
+	return &KeySet{active: k, keys: map[string]*SigningKey{k.ID: k}}, nil
+}
+
+func parseKeyPEM(kid string, raw []byte) (*SigningKey, error) {
+	block, _ := pem.Decode(raw)
+	if block == nil {
+		return nil, errors.New("no PEM block")
+	}
+	k := &SigningKey{ID: kid}
+	switch block.Type {
+	case "PRIVATE KEY":
+		priv, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		signer, ok := priv.(crypto.Signer)
+		if !ok {
+			return nil, errors.New("unsupported private key")
+		}
+		k.Private = signer
+		k.Public = signer.Public()
+	case "PUBLIC KEY":
+		pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		k.Public = pub
+	default:
+		return nil, fmt.Errorf("unsupported PEM type %q", block.Type)
+	}
+
+	switch pub := k.Public.(type) {
+	case *rsa.PublicKey:
+		if pub.N.BitLen() < 2048 {
+			return nil, errors.New("RSA keys must be at least 2048 bits")
+		}
+		k.Method = jwt.SigningMethodRS256
+	case ed25519.PublicKey:
+		k.Method = jwt.SigningMethodEdDSA
+	default:
+		return nil, errors.New("only RSA and Ed25519 keys are supported")
+	}
+	return k, nil
+}
+
+// Sign issues a token with the active key and sets its kid header.
+func (ks *KeySet) Sign(claims jwt.Claims) (string, error) {
+	t := jwt.NewWithClaims(ks.active.Method, claims)
+	t.Header["kid"] = ks.active.ID
+	return t.SignedString(ks.active.Private)
+}
+
+// Parse verifies tok into claims. The algorithm is pinned to the key the kid
+// names, so a token can't pick its own verification method.
+func (ks *KeySet) Parse(tok string, claims jwt.Claims, opts ...jwt.ParserOption) error {
+	_, err := jwt.ParseWithClaims(tok, claims, func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+		if kid == "" {
+			kid = LegacyKID // tokens issued before rotation carry no kid
+		}
+		k, ok := ks.keys[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown key %q", kid)
+		}
+		if t.Method.Alg() != k.Method.Alg() {
+			return nil, fmt.Errorf("unexpected signing method %s", t.Method.Alg())
+		}
+		return k.Public, nil
+	}, append([]jwt.ParserOption{jwt.WithValidMethods(ks.algs()), jwt.WithExpirationRequired()}, opts...)...)
+	return err
+}
+
+func (ks *KeySet) algs() []string {
+	seen := map[string]bool{}
+	var out []string
+	for _, k := range ks.keys {
+		if a := k.Method.Alg(); !seen[a] {
+			seen[a] = true
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
+// JWK is a public key in RFC 7517 form.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// JWKS lists every asymmetric verification key. The legacy HMAC secret is
+// never published.
+func (ks *KeySet) JWKS() map[string][]JWK {
+	keys := []JWK{}
+	for _, k := range ks.keys {
+		switch pub := k.Public.(type) {
+		case *rsa.PublicKey:
+			keys = append(keys, JWK{
+				Kty: "RSA", Kid: k.ID, Use: "sig", Alg: k.Method.Alg(),
+				N: base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+				E: base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			keys = append(keys, JWK{
+				Kty: "OKP", Kid: k.ID, Use: "sig", Alg: k.Method.Alg(),
+				Crv: "Ed25519", X: base64.RawURLEncoding.EncodeToString(pub),
+			})
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Kid < keys[j].Kid })
+	return map[string][]JWK{"keys": keys}
+}
@@ -5,10 +5,11 @@ import (
 	"strings"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/olamideolayemi/framelane-api/internal/models"
 )
 
-func RequireAuth(secret string) gin.HandlerFunc {
+func RequireAuth(keys *KeySet) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		h := c.GetHeader("Authorization")
 		if !strings.HasPrefix(h, "Bearer ") {
@@ -16,26 +17,70 @@ func RequireAuth(secret string) gin.HandlerFunc {
 			return
 		}
 		tok := strings.TrimPrefix(h, "Bearer ")
-		claims := &Claims{}
-		_, err := jwt.ParseWithClaims(tok, claims, func(t *jwt.Token) (any, error) {
-			return []byte(secret), nil
-		})
+		claims, err := ParseSessionToken(keys, tok)
 		if err != nil {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
 			return
 		}
 		c.Set("uid", claims.UserID)
 		c.Set("admin", claims.IsAdmin)
+		c.Set("mfa", claims.MFA)
 		c.Next()
 	}
 }
 
+// RequireAdmin lets through admins whose session passed 2FA. Admins without
+// 2FA can still reach the user routes to enroll.
 func RequireAdmin() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		if isAdmin, _ := c.Get("admin"); isAdmin != true {
 			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "admin only"})
 			return
 		}
+		if mfa, _ := c.Get("mfa"); mfa != true {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "two-factor authentication required for admin accounts"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireAuthOrAPIKey accepts either a bearer token or an X-API-Key header.
+// API key requests run as the key's owner, with "apiKey" set in the context.
+func RequireAuthOrAPIKey(keys *KeySet, apiKeys *APIKeys) gin.HandlerFunc {
+	bearer := RequireAuth(keys)
+	return func(c *gin.Context) {
+		raw := c.GetHeader("X-API-Key")
+		if raw == "" {
+			bearer(c)
+			return
+		}
+		k, err := apiKeys.Authenticate(raw)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid api key"})
+			return
+		}
+		if !apiKeys.Allow(k) {
+			c.Header("Retry-After", "60")
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "api key rate limit exceeded"})
+			return
+		}
+		c.Set("uid", k.UserID.String())
+		c.Set("admin", false)
+		c.Set("apiKey", k)
+		c.Next()
+	}
+}
+
+// RequireScope checks the scope on API key requests; user sessions pass through.
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if v, ok := c.Get("apiKey"); ok {
+			if k, _ := v.(*models.APIKey); k == nil || !k.HasScope(scope) {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "api key missing scope " + scope})
+				return
+			}
+		}
 		c.Next()
 	}
 }
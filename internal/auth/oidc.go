@@ -0,0 +1,221 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// jwksTTL is how long fetched provider keys are trusted before a refresh.
+// An unknown kid forces an early refresh; fetches start at most once per
+// jwksMinRefresh.
+const (
+	jwksTTL        = time.Hour
+	jwksMinRefresh = time.Minute
+)
+
+// OIDCProvider verifies ID tokens from one issuer (Google, Apple, ...).
+type OIDCProvider struct {
+	Name      string
+	Issuer    string
+	JWKSURL   string   // found through the issuer's discovery document when empty
+	ClientIDs []string // accepted audiences (web, iOS, Android client IDs)
+	HTTP      *http.Client
+
+	mu          sync.Mutex
+	keys        map[string]any
+	jwksURL     string
+	fetchedAt   time.Time
+	lastAttempt time.Time
+}
+
+// IDClaims are the ID token claims we rely on.
+type IDClaims struct {
+	Email         string `json:"email"`
+	EmailVerified any    `json:"email_verified"` // bool from Google, "true" from Apple
+	Name          string `json:"name"`
+	Nonce         string `json:"nonce"`
+	jwt.RegisteredClaims
+}
+
+func (c *IDClaims) Verified() bool {
+	switch v := c.EmailVerified.(type) {
+	case bool:
+		return v
+	case string:
+		return v == "true"
+	}
+	return false
+}
+
+// Verify checks signature, issuer, audience, expiry and (when given) nonce.
+func (p *OIDCProvider) Verify(ctx context.Context, raw, nonce string) (*IDClaims, error) {
+	claims := &IDClaims{}
+	_, err := jwt.ParseWithClaims(raw, claims, func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+		return p.key(ctx, kid)
+	},
+		jwt.WithValidMethods([]string{"RS256", "ES256"}),
+		jwt.WithIssuer(p.Issuer),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if !p.audienceOK(claims.Audience) {
+		return nil, errors.New("id token audience mismatch")
+	}
+	if nonce != "" && claims.Nonce != nonce {
+		return nil, errors.New("id token nonce mismatch")
+	}
+	if claims.Subject == "" {
+		return nil, errors.New("id token has no subject")
+	}
+	return claims, nil
+}
+
+func (p *OIDCProvider) audienceOK(aud jwt.ClaimStrings) bool {
+	for _, a := range aud {
+		for _, id := range p.ClientIDs {
+			if a == id {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// key returns the public key for kid. The JWKS is fetched without holding the
+// lock, so logins keep verifying against cached keys while it downloads.
+func (p *OIDCProvider) key(ctx context.Context, kid string) (any, error) {
+	p.mu.Lock()
+	k, ok := p.keys[kid]
+	if ok && time.Since(p.fetchedAt) <= jwksTTL {
+		p.mu.Unlock()
+		return k, nil
+	}
+	if time.Since(p.lastAttempt) < jwksMinRefresh {
+		p.mu.Unlock()
+		if ok {
+			return k, nil // a refresh is in flight or just failed
+		}
+		return nil, fmt.Errorf("%s: unknown signing key %q", p.Name, kid)
+	}
+	p.lastAttempt = time.Now()
+	jwksURL := p.jwksURL
+	p.mu.Unlock()
+
+	keys, jwksURL, err := p.fetch(ctx, jwksURL)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err != nil {
+		if ok {
+			return k, nil // keep using the cached key if the provider is down
+		}
+		return nil, err
+	}
+	p.keys, p.jwksURL, p.fetchedAt = keys, jwksURL, time.Now()
+	if k, ok := keys[kid]; ok {
+		return k, nil
+	}
+	return nil, fmt.Errorf("%s: unknown signing key %q", p.Name, kid)
+}
+
+// fetch downloads the provider's keys, discovering the JWKS URL first if
+// neither JWKSURL nor an earlier discovery gave one.
+func (p *OIDCProvider) fetch(ctx context.Context, jwksURL string) (map[string]any, string, error) {
+	if jwksURL == "" {
+		jwksURL = p.JWKSURL
+	}
+	if jwksURL == "" {
+		var doc struct {
+			Issuer  string `json:"issuer"`
+			JWKSURI string `json:"jwks_uri"`
+		}
+		if err := p.getJSON(ctx, strings.TrimSuffix(p.Issuer, "/")+"/.well-known/openid-configuration", &doc); err != nil {
+			return nil, "", err
+		}
+		if doc.Issuer != p.Issuer || doc.JWKSURI == "" {
+			return nil, "", fmt.Errorf("%s: discovery document is for issuer %q", p.Name, doc.Issuer)
+		}
+		jwksURL = doc.JWKSURI
+	}
+
+	var set struct {
+		Keys []JWK `json:"keys"`
+	}
+	if err := p.getJSON(ctx, jwksURL, &set); err != nil {
+		return nil, "", err
+	}
+	keys := map[string]any{}
+	for _, j := range set.Keys {
+		if pub, err := j.publicKey(); err == nil {
+			keys[j.Kid] = pub
+		}
+	}
+	return keys, jwksURL, nil
+}
+
+func (p *OIDCProvider) getJSON(ctx context.Context, url string, v any) error {
+	client := p.HTTP
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: GET %s returned %d", p.Name, url, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+func (j JWK) publicKey() (any, error) {
+	dec := base64.RawURLEncoding.DecodeString
+	switch j.Kty {
+	case "RSA":
+		n, err := dec(j.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := dec(j.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		if j.Crv != "P-256" {
+			return nil, errors.New("unsupported curve")
+		}
+		x, err := dec(j.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := dec(j.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	}
+	return nil, errors.New("unsupported key type")
+}
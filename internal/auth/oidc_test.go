@@ -0,0 +1,266 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// fakeIssuer serves a discovery document and a JWKS that tests can rotate.
+type fakeIssuer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	keys     map[string]*rsa.PrivateKey
+	jwksHits int
+	block    chan struct{} // when set, JWKS requests wait for it to close
+	started  chan struct{} // receives once per JWKS request
+}
+
+func newFakeIssuer(t *testing.T, kids ...string) *fakeIssuer {
+	t.Helper()
+	f := &fakeIssuer{keys: map[string]*rsa.PrivateKey{}, started: make(chan struct{}, 16)}
+	for _, kid := range kids {
+		f.keys[kid] = newRSAKey(t)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{"issuer": f.URL, "jwks_uri": f.URL + "/keys"})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		f.jwksHits++
+		block := f.block
+		f.mu.Unlock()
+		f.started <- struct{}{}
+		if block != nil {
+			<-block
+		}
+
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		set := struct {
+			Keys []JWK `json:"keys"`
+		}{Keys: []JWK{}}
+		for kid, k := range f.keys {
+			set.Keys = append(set.Keys, JWK{
+				Kty: "RSA", Kid: kid, Use: "sig", Alg: "RS256",
+				N: base64.RawURLEncoding.EncodeToString(k.N.Bytes()),
+				E: base64.RawURLEncoding.EncodeToString(big.NewInt(int64(k.E)).Bytes()),
+			})
+		}
+		json.NewEncoder(w).Encode(set)
+	})
+	f.Server = httptest.NewServer(mux)
+	t.Cleanup(f.Close)
+	return f
+}
+
+func newRSAKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	k, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return k
+}
+
+func (f *fakeIssuer) rotate(t *testing.T, kid string) {
+	k := newRSAKey(t)
+	f.mu.Lock()
+	f.keys = map[string]*rsa.PrivateKey{kid: k}
+	f.mu.Unlock()
+}
+
+func (f *fakeIssuer) hits() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.jwksHits
+}
+
+func (f *fakeIssuer) provider() *OIDCProvider {
+	return &OIDCProvider{Name: "fake", Issuer: f.URL, ClientIDs: []string{"web-client"}, HTTP: f.Client()}
+}
+
+// claims are valid for the provider from f.provider() unless edited.
+func (f *fakeIssuer) claims() jwt.MapClaims {
+	return jwt.MapClaims{
+		"iss":            f.URL,
+		"aud":            "web-client",
+		"sub":            "user-123",
+		"email":          "ada@example.com",
+		"email_verified": true,
+		"nonce":          "n-1",
+		"exp":            time.Now().Add(time.Hour).Unix(),
+		"iat":            time.Now().Unix(),
+	}
+}
+
+func (f *fakeIssuer) sign(t *testing.T, kid string, claims jwt.MapClaims) string {
+	t.Helper()
+	f.mu.Lock()
+	k := f.keys[kid]
+	f.mu.Unlock()
+	return signWith(t, k, kid, claims)
+}
+
+func signWith(t *testing.T, k *rsa.PrivateKey, kid string, claims jwt.MapClaims) string {
+	t.Helper()
+	tok := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	tok.Header["kid"] = kid
+	raw, err := tok.SignedString(k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+func TestOIDCDiscovery(t *testing.T) {
+	f := newFakeIssuer(t, "k1")
+	p := f.provider()
+
+	claims, err := p.Verify(context.Background(), f.sign(t, "k1", f.claims()), "n-1")
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if claims.Subject != "user-123" || claims.Email != "ada@example.com" || !claims.Verified() {
+		t.Fatalf("unexpected claims %+v", claims)
+	}
+	if p.jwksURL != f.URL+"/keys" {
+		t.Fatalf("jwks url = %q, want it from discovery", p.jwksURL)
+	}
+}
+
+func TestOIDCDiscoveryIssuerMismatch(t *testing.T) {
+	f := newFakeIssuer(t, "k1")
+	p := f.provider()
+	p.Issuer = f.URL + "/other"
+
+	c := f.claims()
+	c["iss"] = p.Issuer
+	if _, err := p.Verify(context.Background(), f.sign(t, "k1", c), ""); err == nil {
+		t.Fatal("accepted keys from a discovery document for another issuer")
+	}
+}
+
+func TestOIDCKeyRotation(t *testing.T) {
+	f := newFakeIssuer(t, "k1")
+	p := f.provider()
+	ctx := context.Background()
+
+	if _, err := p.Verify(ctx, f.sign(t, "k1", f.claims()), ""); err != nil {
+		t.Fatalf("verify with k1: %v", err)
+	}
+	f.rotate(t, "k2")
+	rotated := f.sign(t, "k2", f.claims())
+
+	// An unknown kid right after a fetch doesn't hammer the provider
+	if _, err := p.Verify(ctx, rotated, ""); err == nil {
+		t.Fatal("verified k2 without refreshing")
+	}
+	if n := f.hits(); n != 1 {
+		t.Fatalf("jwks fetched %d times, want 1", n)
+	}
+
+	p.lastAttempt = time.Now().Add(-2 * jwksMinRefresh)
+	if _, err := p.Verify(ctx, rotated, ""); err != nil {
+		t.Fatalf("verify with k2 after refresh: %v", err)
+	}
+	if n := f.hits(); n != 2 {
+		t.Fatalf("jwks fetched %d times, want 2", n)
+	}
+}
+
+func TestOIDCRefreshDoesNotBlockCachedKeys(t *testing.T) {
+	f := newFakeIssuer(t, "k1")
+	p := f.provider()
+	ctx := context.Background()
+	tok := f.sign(t, "k1", f.claims())
+	if _, err := p.Verify(ctx, tok, ""); err != nil {
+		t.Fatal(err)
+	}
+	<-f.started
+
+	// Keys go stale and the next refresh hangs at the provider
+	release := make(chan struct{})
+	f.mu.Lock()
+	f.block = release
+	f.mu.Unlock()
+	p.mu.Lock()
+	p.fetchedAt = time.Now().Add(-2 * jwksTTL)
+	p.lastAttempt = p.fetchedAt
+	p.mu.Unlock()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := p.Verify(ctx, tok, "")
+		done <- err
+	}()
+	<-f.started
+
+	verified := make(chan error, 1)
+	go func() {
+		_, err := p.Verify(ctx, tok, "")
+		verified <- err
+	}()
+	select {
+	case err := <-verified:
+		if err != nil {
+			t.Fatalf("verify during refresh: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("verify blocked behind the JWKS refresh")
+	}
+
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("verify that triggered the refresh: %v", err)
+	}
+}
+
+func TestOIDCRejects(t *testing.T) {
+	f := newFakeIssuer(t, "k1")
+	p := f.provider()
+	ctx := context.Background()
+
+	tests := []struct {
+		name  string
+		edit  func(jwt.MapClaims)
+		token func(jwt.MapClaims) string
+		nonce string
+	}{
+		{name: "bad signature", token: func(c jwt.MapClaims) string { return signWith(t, newRSAKey(t), "k1", c) }},
+		{name: "wrong audience", edit: func(c jwt.MapClaims) { c["aud"] = "someone-else" }},
+		{name: "wrong issuer", edit: func(c jwt.MapClaims) { c["iss"] = "https://evil.example" }},
+		{name: "expired", edit: func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Hour).Unix() }},
+		{name: "no expiry", edit: func(c jwt.MapClaims) { delete(c, "exp") }},
+		{name: "nonce mismatch", nonce: "n-2"},
+		{name: "no nonce in token", edit: func(c jwt.MapClaims) { delete(c, "nonce") }, nonce: "n-1"},
+		{name: "no subject", edit: func(c jwt.MapClaims) { delete(c, "sub") }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := f.claims()
+			if tt.edit != nil {
+				tt.edit(c)
+			}
+			raw := f.sign(t, "k1", c)
+			if tt.token != nil {
+				raw = tt.token(c)
+			}
+			if _, err := p.Verify(ctx, raw, tt.nonce); err == nil {
+				t.Fatal("token was accepted")
+			}
+		})
+	}
+}
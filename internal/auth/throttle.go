@@ -0,0 +1,122 @@
+package auth
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/olamideolayemi/framelane-api/internal/models"
+)
+
+// Throttle tracks failed logins per account and per client IP. Each account
+// failure doubles the wait before the next attempt; reaching the limit locks
+// the key for Lockout. IPs are only locked, never slowed, since many
+// customers can share one address. Account keys use the email alone, so a
+// client that changes its apparent IP still hits the account lock.
+type Throttle struct {
+	DB            *gorm.DB
+	MaxFailures   int           // per account before lockout
+	MaxIPFailures int           // per IP before lockout
+	Lockout       time.Duration // lock length, also how long failures are remembered
+	BaseDelay     time.Duration // first backoff step for accounts
+}
+
+func AccountKey(email string) string { return "acct:" + strings.ToLower(strings.TrimSpace(email)) }
+func IPKey(ip string) string         { return "ip:" + ip }
+
+// Wait returns how long the caller has to wait before another login attempt
+// for this email from this IP is allowed. Zero means go ahead.
+func (t *Throttle) Wait(email, ip string) (time.Duration, error) {
+	var rows []models.LoginThrottle
+	if err := t.DB.Where("key IN ?", []string{AccountKey(email), IPKey(ip)}).Find(&rows).Error; err != nil {
+		return 0, err
+	}
+	now := time.Now()
+	var wait time.Duration
+	for _, r := range rows {
+		var w time.Duration
+		switch {
+		case r.LockedUntil != nil && r.LockedUntil.After(now):
+			w = r.LockedUntil.Sub(now)
+		case strings.HasPrefix(r.Key, "acct:") && r.Failures > 0 && now.Sub(r.LastFailureAt) < t.Lockout:
+			w = r.LastFailureAt.Add(t.backoff(r.Failures)).Sub(now)
+		}
+		if w > wait {
+			wait = w
+		}
+	}
+	return wait, nil
+}
+
+func (t *Throttle) backoff(failures int) time.Duration {
+	d := t.BaseDelay
+	for i := 1; i < failures && d < t.Lockout; i++ {
+		d *= 2
+	}
+	if d > t.Lockout {
+		d = t.Lockout
+	}
+	return d
+}
+
+// RecordFailure counts a failed attempt against the account and the IP.
+// lockedUntil is set when this failure locked the account.
+func (t *Throttle) RecordFailure(email, ip string) (lockedUntil *time.Time, err error) {
+	lockedUntil, err = t.fail(AccountKey(email), t.MaxFailures)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := t.fail(IPKey(ip), t.MaxIPFailures); err != nil {
+		return nil, err
+	}
+	return lockedUntil, nil
+}
+
+func (t *Throttle) fail(key string, limit int) (*time.Time, error) {
+	var locked *time.Time
+	err := t.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&models.LoginThrottle{Key: key}).Error; err != nil {
+			return err
+		}
+		var r models.LoginThrottle
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&r, "key = ?", key).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if r.LockedUntil != nil && r.LockedUntil.After(now) {
+			return nil // already locked, nothing more to count
+		}
+		if now.Sub(r.LastFailureAt) > t.Lockout {
+			r.Failures = 0 // old failures have aged out
+		}
+		r.Failures++
+		r.LastFailureAt = now
+		r.LockedUntil = nil
+		if limit > 0 && r.Failures >= limit {
+			until := now.Add(t.Lockout)
+			r.LockedUntil = &until
+			r.Failures = 0
+			locked = &until
+		}
+		return tx.Save(&r).Error
+	})
+	return locked, err
+}
+
+// RecordSuccess clears the account's failure count. The IP count is left to
+// age out so one valid account can't be used to reset it.
+func (t *Throttle) RecordSuccess(email string) error {
+	_, err := t.Unlock(email)
+	return err
+}
+
+// Unlock removes any failure count or lock on the account and reports
+// whether there was one.
+func (t *Throttle) Unlock(email string) (bool, error) {
+	res := t.DB.Delete(&models.LoginThrottle{}, "key = ?", AccountKey(email))
+	return res.RowsAffected > 0, res.Error
+}
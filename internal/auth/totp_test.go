@@ -0,0 +1,69 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA-1 key from RFC 6238 appendix B.
+var rfcSecret = b32.EncodeToString([]byte("12345678901234567890"))
+
+// The RFC 6238 SHA-1 vectors, cut to the last six of their eight digits.
+func TestTOTPRFC6238(t *testing.T) {
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, tt := range tests {
+		at := time.Unix(tt.unix, 0)
+		if got := totpCode([]byte("12345678901234567890"), tt.unix/totpPeriod); got != tt.code {
+			t.Errorf("code at %d = %s, want %s", tt.unix, got, tt.code)
+		}
+		step, ok := ValidateTOTP(rfcSecret, tt.code, at, 0)
+		if !ok || step != tt.unix/totpPeriod {
+			t.Errorf("ValidateTOTP at %d = %d, %v; want step %d", tt.unix, step, ok, tt.unix/totpPeriod)
+		}
+	}
+}
+
+func TestTOTPAcceptsSecretAndCodeAsTyped(t *testing.T) {
+	code := "287 082"
+	if _, ok := ValidateTOTP(" "+strings.ToLower(rfcSecret)+" ", code, time.Unix(59, 0), 0); !ok {
+		t.Fatal("lower-case secret or spaced code refused")
+	}
+}
+
+func TestTOTPDriftWindow(t *testing.T) {
+	key := []byte("12345678901234567890")
+	now := time.Unix(1111111111, 0)
+	step := now.Unix() / totpPeriod
+
+	for d := int64(-totpSkew - 2); d <= totpSkew+2; d++ {
+		code := totpCode(key, step+d)
+		got, ok := ValidateTOTP(rfcSecret, code, now, 0)
+		want := d >= -totpSkew && d <= totpSkew
+		if ok != want {
+			t.Errorf("code %d steps away: accepted = %v, want %v", d, ok, want)
+		}
+		if ok && got != step+d {
+			t.Errorf("code %d steps away matched step %d, want %d", d, got, step+d)
+		}
+	}
+
+	// A code from the drift window is refused once its step has been used
+	prev := totpCode(key, step-1)
+	if _, ok := ValidateTOTP(rfcSecret, prev, now, step-1); ok {
+		t.Fatal("code accepted again for a used step")
+	}
+	if _, ok := ValidateTOTP(rfcSecret, totpCode(key, step), now, step-1); !ok {
+		t.Fatal("current code refused after an older step was used")
+	}
+}
@@ -0,0 +1,145 @@
+// Package catalog moves frames and frame sizes in and out of CSV so prices
+// can be maintained in a spreadsheet. Imports are planned as a diff first and
+// applied in one transaction.
+package catalog
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/olamideolayemi/framelane-api/internal/models"
+)
+
+// Columns, in export order. Imports match headers case-insensitively and
+// need only the required ones.
+var (
+	SizeColumns  = []string{"id", "name", "price", "status", "width", "height", "unit", "display_order"}
+	FrameColumns = []string{"id", "name", "slug", "status", "material", "color", "moulding_width_in", "display_order", "description"}
+
+	sizeRequired  = []string{"name", "price"}
+	frameRequired = []string{"name"}
+)
+
+func ftoa(v float64) string { return strconv.FormatFloat(v, 'f', -1, 64) }
+
+// formulaPrefixes make spreadsheets evaluate a cell as a formula.
+const formulaPrefixes = "=+-@\t\r"
+
+// cell quotes text a spreadsheet would otherwise run as a formula. Imports
+// drop the quote again, so an export round-trips unchanged.
+func cell(s string) string {
+	if s != "" && strings.ContainsRune(formulaPrefixes, rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+// uncell undoes cell.
+func uncell(s string) string {
+	if len(s) > 1 && s[0] == '\'' && strings.ContainsRune(formulaPrefixes, rune(s[1])) {
+		return s[1:]
+	}
+	return s
+}
+
+// ExportSizes writes sizes as CSV with a header row.
+func ExportSizes(w io.Writer, sizes []models.FrameSize) error {
+	cw := csv.NewWriter(w)
+	cw.Write(SizeColumns)
+	for _, s := range sizes {
+		cw.Write([]string{s.ID.String(), cell(s.Name), strconv.Itoa(s.Price), cell(s.Status),
+			ftoa(s.Width), ftoa(s.Height), cell(s.Unit), strconv.Itoa(s.DisplayOrder)})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// ExportFrames writes frames as CSV with a header row.
+func ExportFrames(w io.Writer, frames []models.Frame) error {
+	cw := csv.NewWriter(w)
+	cw.Write(FrameColumns)
+	for _, f := range frames {
+		cw.Write([]string{f.ID.String(), cell(f.Name), cell(f.Slug), cell(f.Status), cell(f.Material), cell(f.Color),
+			ftoa(f.MouldingWidthIn), strconv.Itoa(f.DisplayOrder), cell(f.Description)})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// row is one CSV record keyed by lower-case column name. Blank cells are
+// left out, meaning "keep the current value" on updates.
+type row struct {
+	line   int
+	values map[string]string
+}
+
+func (r row) get(col string) (string, bool) {
+	v, ok := r.values[col]
+	return v, ok
+}
+
+// RowError points at a CSV line that can't be imported.
+type RowError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+func readRows(r io.Reader, columns, required []string) ([]row, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("file is empty")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	known := map[string]bool{}
+	for _, c := range columns {
+		known[c] = true
+	}
+	index := map[string]int{}
+	for i, h := range header {
+		h = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff"))) // spreadsheets add a BOM
+		if !known[h] {
+			return nil, fmt.Errorf("unknown column %q, expected some of %s", h, strings.Join(columns, ", "))
+		}
+		if _, dup := index[h]; dup {
+			return nil, fmt.Errorf("column %q appears twice", h)
+		}
+		index[h] = i
+	}
+	for _, c := range required {
+		if _, ok := index[c]; !ok {
+			return nil, fmt.Errorf("missing required column %q", c)
+		}
+	}
+
+	var rows []row
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := cr.FieldPos(0)
+		r := row{line: line, values: map[string]string{}}
+		blank := true
+		for col, i := range index {
+			if i < len(rec) && strings.TrimSpace(rec[i]) != "" {
+				r.values[col] = uncell(strings.TrimSpace(rec[i]))
+				blank = false
+			}
+		}
+		if !blank {
+			rows = append(rows, r)
+		}
+	}
+	return rows, nil
+}
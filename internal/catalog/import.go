@@ -0,0 +1,421 @@
+package catalog
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/olamideolayemi/framelane-api/internal/models"
+	"github.com/olamideolayemi/framelane-api/internal/pricing"
+)
+
+// Change is one field an import would change.
+type Change struct {
+	Field string `json:"field"`
+	From  any    `json:"from"`
+	To    any    `json:"to"`
+}
+
+// Item is one create, update or deactivation in a plan.
+type Item struct {
+	ID      uuid.UUID `json:"id"`
+	Name    string    `json:"name"`
+	Line    int       `json:"line,omitempty"` // CSV line, 0 for deactivations
+	Changes []Change  `json:"changes,omitempty"`
+}
+
+// Plan is the diff an import would make. Deactivations are catalogue rows
+// missing from the file; they are marked out of stock, not deleted.
+type Plan struct {
+	Creates       []Item     `json:"creates"`
+	Updates       []Item     `json:"updates"`
+	Deactivations []Item     `json:"deactivations"`
+	Errors        []RowError `json:"errors"`
+
+	save []func(tx *gorm.DB) error
+}
+
+// Apply writes the plan. Run it inside a transaction so a failure leaves the
+// catalogue untouched.
+func (p *Plan) Apply(tx *gorm.DB) error {
+	for _, fn := range p.save {
+		if err := fn(tx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Hash identifies what the plan would do, so an import can check it still
+// matches the dry run the admin reviewed. IDs of rows to create are left out:
+// every planning picks new ones.
+func (p *Plan) Hash() string {
+	creates := make([]Item, len(p.Creates))
+	for i, it := range p.Creates {
+		it.ID = uuid.Nil
+		creates[i] = it
+	}
+	b, _ := json.Marshal([]any{creates, p.Updates, p.Deactivations, p.Errors})
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func newPlan() *Plan {
+	return &Plan{Creates: []Item{}, Updates: []Item{}, Deactivations: []Item{}, Errors: []RowError{}}
+}
+
+func (p *Plan) fail(r row, format string, args ...any) {
+	p.Errors = append(p.Errors, RowError{Line: r.line, Error: fmt.Sprintf(format, args...)})
+}
+
+// fieldSetter records a change when a CSV cell differs from the current value.
+type fieldSetter struct {
+	changes []Change
+}
+
+func (f *fieldSetter) str(field string, dst *string, v string) {
+	if *dst != v {
+		f.changes = append(f.changes, Change{Field: field, From: *dst, To: v})
+		*dst = v
+	}
+}
+
+func (f *fieldSetter) num(field string, dst *int, v int) {
+	if *dst != v {
+		f.changes = append(f.changes, Change{Field: field, From: *dst, To: v})
+		*dst = v
+	}
+}
+
+func (f *fieldSetter) float(field string, dst *float64, v float64) {
+	if *dst != v {
+		f.changes = append(f.changes, Change{Field: field, From: *dst, To: v})
+		*dst = v
+	}
+}
+
+func validStatus(s string) bool { return s == "available" || s == "out_of_stock" }
+
+// archivedKeys returns the lower-cased col of archived rows, which keep their
+// unique names and slugs until restored.
+func archivedKeys(db *gorm.DB, model any, col string) (map[string]bool, error) {
+	var vals []string
+	if err := db.Unscoped().Model(model).Where("deleted_at IS NOT NULL").Pluck(col, &vals).Error; err != nil {
+		return nil, err
+	}
+	keys := map[string]bool{}
+	for _, v := range vals {
+		keys[strings.ToLower(v)] = true
+	}
+	return keys, nil
+}
+
+// PlanSizes diffs a sizes CSV against the catalogue. by is the importing
+// admin, recorded on price versions.
+func PlanSizes(db *gorm.DB, r io.Reader, by *uuid.UUID) (*Plan, error) {
+	rows, err := readRows(r, SizeColumns, sizeRequired)
+	if err != nil {
+		return nil, err
+	}
+	var existing []models.FrameSize
+	if err := db.Where("custom = ?", false).Order("id").Find(&existing).Error; err != nil {
+		return nil, err
+	}
+	byID, byName := map[uuid.UUID]*models.FrameSize{}, map[string]*models.FrameSize{}
+	for i := range existing {
+		byID[existing[i].ID] = &existing[i]
+		byName[strings.ToLower(existing[i].Name)] = &existing[i]
+	}
+	archived, err := archivedKeys(db, &models.FrameSize{}, "name")
+	if err != nil {
+		return nil, err
+	}
+	nextOrder, err := models.NextSizeOrder(db)
+	if err != nil {
+		return nil, err
+	}
+
+	p := newPlan()
+	seen := map[uuid.UUID]bool{}
+	names := map[string]int{}
+	for _, r := range rows {
+		name, _ := r.get("name")
+		if prev, dup := names[strings.ToLower(name)]; dup {
+			p.fail(r, "name %q already used on line %d", name, prev)
+			continue
+		}
+		names[strings.ToLower(name)] = r.line
+
+		var size models.FrameSize
+		isNew := true
+		if v, ok := r.get("id"); ok {
+			id, err := uuid.Parse(v)
+			if err != nil || byID[id] == nil {
+				p.fail(r, "unknown id %q", v)
+				continue
+			}
+			size, isNew = *byID[id], false
+		} else if s := byName[strings.ToLower(name)]; s != nil {
+			size, isNew = *s, false
+		} else {
+			size = models.FrameSize{ID: uuid.New(), Status: "available", Unit: models.UnitInch}
+		}
+		if other := byName[strings.ToLower(name)]; other != nil && other.ID != size.ID {
+			p.fail(r, "name %q belongs to another size", name)
+			continue
+		}
+		if archived[strings.ToLower(name)] {
+			p.fail(r, "name %q belongs to an archived size, restore it first", name)
+			continue
+		}
+		seen[size.ID] = true
+
+		var f fieldSetter
+		f.str("name", &size.Name, name)
+		v, _ := r.get("price")
+		price, err := strconv.Atoi(v)
+		if err != nil || price <= 0 {
+			p.fail(r, "price must be a positive whole number")
+			continue
+		}
+		oldPrice := size.Price
+		f.num("price", &size.Price, price)
+		if v, ok := r.get("status"); ok {
+			if !validStatus(v) {
+				p.fail(r, "status must be available or out_of_stock")
+				continue
+			}
+			f.str("status", &size.Status, v)
+		}
+		if v, ok := r.get("display_order"); ok {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				p.fail(r, "display_order must be a whole number")
+				continue
+			}
+			f.num("display_order", &size.DisplayOrder, n)
+		} else if isNew {
+			f.num("display_order", &size.DisplayOrder, nextOrder)
+			nextOrder += 10
+		}
+
+		w, h, unit := size.Width, size.Height, size.Unit
+		_, hasW := r.get("width")
+		_, hasH := r.get("height")
+		if hasW || hasH {
+			ws, _ := r.get("width")
+			hs, _ := r.get("height")
+			w, err = strconv.ParseFloat(ws, 64)
+			if err == nil {
+				h, err = strconv.ParseFloat(hs, 64)
+			}
+			if err != nil || w <= 0 || h <= 0 {
+				p.fail(r, "width and height must both be positive numbers")
+				continue
+			}
+		} else if isNew || w <= 0 || h <= 0 {
+			var ok bool
+			if w, h, unit, ok = models.ParseSizeName(name); !ok {
+				p.fail(r, "give width and height, or a name like '16x20 in'")
+				continue
+			}
+		}
+		if v, ok := r.get("unit"); ok {
+			unit = v
+		}
+		if !models.ValidUnit(unit) {
+			p.fail(r, "unit must be in, cm or mm")
+			continue
+		}
+		f.float("width", &size.Width, w)
+		f.float("height", &size.Height, h)
+		f.str("unit", &size.Unit, unit)
+		size.Orientation = models.OrientationOf(size.Width, size.Height)
+
+		s := size
+		priceChanged := isNew || oldPrice != s.Price
+		if isNew {
+			p.Creates = append(p.Creates, Item{ID: s.ID, Name: s.Name, Line: r.line, Changes: f.changes})
+			p.save = append(p.save, func(tx *gorm.DB) error { return tx.Create(&s).Error })
+		} else if len(f.changes) > 0 {
+			p.Updates = append(p.Updates, Item{ID: s.ID, Name: s.Name, Line: r.line, Changes: f.changes})
+			p.save = append(p.save, func(tx *gorm.DB) error { return tx.Save(&s).Error })
+		} else {
+			continue
+		}
+		if priceChanged {
+			p.save = append(p.save, func(tx *gorm.DB) error {
+				return pricing.Record(tx, s.ID, s.Price, "CSV import", by)
+			})
+		}
+	}
+
+	for _, s := range existing {
+		if !seen[s.ID] && s.Status != "out_of_stock" {
+			id := s.ID
+			p.Deactivations = append(p.Deactivations, Item{ID: id, Name: s.Name,
+				Changes: []Change{{Field: "status", From: s.Status, To: "out_of_stock"}}})
+			p.save = append(p.save, func(tx *gorm.DB) error {
+				return tx.Model(&models.FrameSize{}).Where("id = ?", id).Update("status", "out_of_stock").Error
+			})
+		}
+	}
+	return p, nil
+}
+
+// PlanFrames diffs a frames CSV against the catalogue.
+func PlanFrames(db *gorm.DB, r io.Reader) (*Plan, error) {
+	rows, err := readRows(r, FrameColumns, frameRequired)
+	if err != nil {
+		return nil, err
+	}
+	var existing []models.Frame
+	if err := db.Order("id").Find(&existing).Error; err != nil {
+		return nil, err
+	}
+	byID, byName, bySlug := map[uuid.UUID]*models.Frame{}, map[string]*models.Frame{}, map[string]*models.Frame{}
+	for i := range existing {
+		byID[existing[i].ID] = &existing[i]
+		byName[strings.ToLower(existing[i].Name)] = &existing[i]
+		bySlug[existing[i].Slug] = &existing[i]
+	}
+	archivedNames, err := archivedKeys(db, &models.Frame{}, "name")
+	if err != nil {
+		return nil, err
+	}
+	archivedSlugs, err := archivedKeys(db, &models.Frame{}, "slug")
+	if err != nil {
+		return nil, err
+	}
+
+	p := newPlan()
+	seen := map[uuid.UUID]bool{}
+	names, slugs := map[string]int{}, map[string]int{}
+	for _, r := range rows {
+		name, _ := r.get("name")
+		if prev, dup := names[strings.ToLower(name)]; dup {
+			p.fail(r, "name %q already used on line %d", name, prev)
+			continue
+		}
+		names[strings.ToLower(name)] = r.line
+
+		var frame models.Frame
+		isNew := true
+		slug, hasSlug := r.get("slug")
+		if v, ok := r.get("id"); ok {
+			id, err := uuid.Parse(v)
+			if err != nil || byID[id] == nil {
+				p.fail(r, "unknown id %q", v)
+				continue
+			}
+			frame, isNew = *byID[id], false
+		} else if f := bySlug[slug]; hasSlug && f != nil {
+			frame, isNew = *f, false
+		} else if f := byName[strings.ToLower(name)]; f != nil {
+			frame, isNew = *f, false
+		} else {
+			frame = models.Frame{ID: uuid.New(), Status: "available"}
+		}
+		if other := byName[strings.ToLower(name)]; other != nil && other.ID != frame.ID {
+			p.fail(r, "name %q belongs to another frame", name)
+			continue
+		}
+		if archivedNames[strings.ToLower(name)] {
+			p.fail(r, "name %q belongs to an archived frame, restore it first", name)
+			continue
+		}
+		if !hasSlug {
+			slug = frame.Slug
+			if slug == "" {
+				slug = models.DefaultSlug(name)
+			}
+		}
+		if slices.Contains(models.ReservedSlugs, slug) {
+			p.fail(r, "slug %q is reserved, choose another", slug)
+			continue
+		}
+		if !models.ValidSlug(slug) {
+			p.fail(r, "slug may only contain lowercase letters, digits and dashes")
+			continue
+		}
+		if other := bySlug[slug]; other != nil && other.ID != frame.ID {
+			p.fail(r, "slug %q belongs to another frame", slug)
+			continue
+		}
+		if archivedSlugs[slug] {
+			p.fail(r, "slug %q belongs to an archived frame, restore it first", slug)
+			continue
+		}
+		if prev, dup := slugs[slug]; dup {
+			p.fail(r, "slug %q already used on line %d", slug, prev)
+			continue
+		}
+		slugs[slug] = r.line
+		seen[frame.ID] = true
+
+		var f fieldSetter
+		f.str("name", &frame.Name, name)
+		f.str("slug", &frame.Slug, slug)
+		if v, ok := r.get("status"); ok {
+			if !validStatus(v) {
+				p.fail(r, "status must be available or out_of_stock")
+				continue
+			}
+			f.str("status", &frame.Status, v)
+		}
+		if v, ok := r.get("material"); ok {
+			f.str("material", &frame.Material, v)
+		}
+		if v, ok := r.get("color"); ok {
+			f.str("color", &frame.Color, v)
+		}
+		if v, ok := r.get("description"); ok {
+			f.str("description", &frame.Description, v)
+		}
+		if v, ok := r.get("moulding_width_in"); ok {
+			w, err := strconv.ParseFloat(v, 64)
+			if err != nil || w < 0 || w > 6 {
+				p.fail(r, "moulding_width_in must be between 0 and 6")
+				continue
+			}
+			f.float("moulding_width_in", &frame.MouldingWidthIn, w)
+		}
+		if v, ok := r.get("display_order"); ok {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				p.fail(r, "display_order must be a whole number")
+				continue
+			}
+			f.num("display_order", &frame.DisplayOrder, n)
+		}
+
+		fr := frame
+		if isNew {
+			p.Creates = append(p.Creates, Item{ID: fr.ID, Name: fr.Name, Line: r.line, Changes: f.changes})
+			p.save = append(p.save, func(tx *gorm.DB) error { return tx.Create(&fr).Error })
+		} else if len(f.changes) > 0 {
+			p.Updates = append(p.Updates, Item{ID: fr.ID, Name: fr.Name, Line: r.line, Changes: f.changes})
+			p.save = append(p.save, func(tx *gorm.DB) error { return tx.Omit("Images").Save(&fr).Error })
+		}
+	}
+
+	for _, fr := range existing {
+		if !seen[fr.ID] && fr.Status != "out_of_stock" {
+			id := fr.ID
+			p.Deactivations = append(p.Deactivations, Item{ID: id, Name: fr.Name,
+				Changes: []Change{{Field: "status", From: fr.Status, To: "out_of_stock"}}})
+			p.save = append(p.save, func(tx *gorm.DB) error {
+				return tx.Model(&models.Frame{}).Where("id = ?", id).Update("status", "out_of_stock").Error
+			})
+		}
+	}
+	return p, nil
+}
@@ -0,0 +1,435 @@
+// Package cleanup removes stored images nothing refers to any more and
+// applies the retention periods to delivered orders and quarantined uploads.
+package cleanup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/olamideolayemi/framelane-api/internal/models"
+	"github.com/olamideolayemi/framelane-api/internal/storage"
+	"github.com/olamideolayemi/framelane-api/internal/thumbs"
+)
+
+// Prefixes the collector manages. Frame textures are catalogue content and
+// are never collected.
+const (
+	uploadsPrefix    = "uploads/"
+	mockupsPrefix    = "mockups/"    // mockups/<userId>/<assetId>/<hash>.jpg
+	rendersPrefix    = "renders/"    // renders/<orderId>/print.jpg
+	quarantinePrefix = "quarantine/" // quarantine/uploads/<userId>/<file>
+)
+
+// Deletion reasons
+const (
+	ReasonOrphan     = "orphan"     // no asset or order refers to the object
+	ReasonUnordered  = "unordered"  // asset never used in an order within the grace period
+	ReasonRetention  = "retention"  // order delivered longer ago than the retention period
+	ReasonQuarantine = "quarantine" // rejected upload held longer than the quarantine period
+)
+
+// maxReportItems caps the per-object list in a report; totals are always complete.
+const maxReportItems = 1000
+
+var ErrRunning = errors.New("a cleanup run is already in progress")
+
+// Collector finds and deletes unreferenced objects.
+type Collector struct {
+	DB    *gorm.DB
+	Store storage.Store
+
+	Grace      time.Duration // leave anything younger than this alone
+	Retention  time.Duration // delete images of orders delivered longer ago; 0 keeps them forever
+	Quarantine time.Duration // delete quarantined uploads rejected longer ago; 0 keeps them forever
+
+	mu      sync.Mutex
+	running bool
+	last    *Report
+}
+
+// Item is one object the run deleted, or would delete in a dry run.
+type Item struct {
+	Key     string    `json:"key"`
+	Size    int64     `json:"size"`
+	Reason  string    `json:"reason"`
+	AssetID uuid.UUID `json:"assetId,omitempty"`
+}
+
+// Report summarises a run.
+type Report struct {
+	DryRun         bool      `json:"dryRun"`
+	StartedAt      time.Time `json:"startedAt"`
+	FinishedAt     time.Time `json:"finishedAt"`
+	Scanned        int       `json:"scanned"`
+	Objects        int       `json:"objects"` // deleted, or to delete in a dry run
+	Bytes          int64     `json:"bytes"`
+	AssetsRemoved  int       `json:"assetsRemoved"`
+	AssetsPurged   int       `json:"assetsPurged"`
+	UploadsAborted int       `json:"uploadsAborted"` // expired multipart uploads
+	Items          []Item    `json:"items"`
+	Truncated      bool      `json:"truncated"`
+	Errors         []string  `json:"errors,omitempty"`
+}
+
+func (r *Report) add(it Item) {
+	r.Objects++
+	r.Bytes += it.Size
+	if len(r.Items) < maxReportItems {
+		r.Items = append(r.Items, it)
+	} else {
+		r.Truncated = true
+	}
+}
+
+func (r *Report) fail(format string, args ...any) {
+	r.Errors = append(r.Errors, fmt.Sprintf(format, args...))
+}
+
+// Last returns the report of the most recent run, or nil.
+func (gc *Collector) Last() *Report {
+	gc.mu.Lock()
+	defer gc.mu.Unlock()
+	return gc.last
+}
+
+// RunEvery collects on a schedule until ctx is done.
+func (gc *Collector) RunEvery(ctx context.Context, every time.Duration, dryRun bool) {
+	t := time.NewTicker(every)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+		r, err := gc.Run(ctx, dryRun)
+		if err != nil {
+			log.Printf("cleanup: %v", err)
+			continue
+		}
+		log.Printf("cleanup: dryRun=%v scanned=%d objects=%d bytes=%d assetsRemoved=%d assetsPurged=%d uploadsAborted=%d errors=%d",
+			r.DryRun, r.Scanned, r.Objects, r.Bytes, r.AssetsRemoved, r.AssetsPurged, r.UploadsAborted, len(r.Errors))
+	}
+}
+
+// Run does one pass. In a dry run nothing is deleted and the report lists
+// what would have been.
+func (gc *Collector) Run(ctx context.Context, dryRun bool) (*Report, error) {
+	gc.mu.Lock()
+	if gc.running {
+		gc.mu.Unlock()
+		return nil, ErrRunning
+	}
+	gc.running = true
+	gc.mu.Unlock()
+	defer func() {
+		gc.mu.Lock()
+		gc.running = false
+		gc.mu.Unlock()
+	}()
+
+	r := &Report{DryRun: dryRun, StartedAt: time.Now(), Items: []Item{}}
+	if err := gc.run(ctx, r); err != nil {
+		return nil, err
+	}
+	r.FinishedAt = time.Now()
+
+	gc.mu.Lock()
+	gc.last = r
+	gc.mu.Unlock()
+	return r, nil
+}
+
+// plan is what a run decided to keep and drop.
+type plan struct {
+	keep      map[string]bool      // object keys still in use
+	liveAsset map[uuid.UUID]bool   // assets whose mockups stay
+	liveOrder map[string]bool      // order IDs whose renders stay
+	drop      map[string]Item      // known keys of dropped assets
+	remove    []uuid.UUID          // assets to delete outright
+	purge     []uuid.UUID          // assets to mark purged
+	dropped   map[uuid.UUID]string // asset -> reason, for their mockups
+}
+
+func (gc *Collector) run(ctx context.Context, r *Report) error {
+	cutoff := r.StartedAt.Add(-gc.Grace)
+	quarantineCutoff := r.StartedAt.Add(-gc.Quarantine)
+	p := plan{
+		keep:      map[string]bool{},
+		liveAsset: map[uuid.UUID]bool{},
+		liveOrder: map[string]bool{},
+		drop:      map[string]Item{},
+		dropped:   map[uuid.UUID]string{},
+	}
+
+	// Assets some order refers to, and those whose delivered order is past retention
+	var ordered []uuid.UUID
+	if err := gc.DB.Model(&models.Order{}).Where("asset_id IS NOT NULL").Distinct().Pluck("asset_id", &ordered).Error; err != nil {
+		return err
+	}
+	inOrder := map[uuid.UUID]bool{}
+	for _, id := range ordered {
+		inOrder[id] = true
+	}
+	expired := map[uuid.UUID]bool{}
+	if gc.Retention > 0 {
+		// An asset shared by several orders is only purged once none is still open
+		var ids []uuid.UUID
+		err := gc.DB.Model(&models.Order{}).
+			Where("asset_id IS NOT NULL").
+			Group("asset_id").
+			Having("bool_and(LOWER(status) = LOWER(?) AND delivered_at IS NOT NULL AND delivered_at < ?)", models.OrderDelivered, r.StartedAt.Add(-gc.Retention)).
+			Pluck("asset_id", &ids).Error
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			expired[id] = true
+		}
+	}
+
+	var assets []models.Asset
+	if err := gc.DB.Where("status <> ?", models.AssetPurged).Find(&assets).Error; err != nil {
+		return err
+	}
+	for _, a := range assets {
+		reason := ""
+		switch {
+		case expired[a.ID]:
+			reason = ReasonRetention
+			p.purge = append(p.purge, a.ID)
+		case a.QuarantineKey != "":
+			// Held for review until the quarantine period is over
+			if gc.Quarantine > 0 && a.UpdatedAt.Before(quarantineCutoff) {
+				reason = ReasonQuarantine
+				p.remove = append(p.remove, a.ID)
+			}
+		case !inOrder[a.ID] && a.CreatedAt.Before(cutoff):
+			reason = ReasonUnordered
+			p.remove = append(p.remove, a.ID)
+		}
+		keys := assetKeys(&a)
+		if reason == "" {
+			p.liveAsset[a.ID] = true
+			for _, k := range keys {
+				p.keep[k] = true
+			}
+			continue
+		}
+		p.dropped[a.ID] = reason
+		for _, k := range keys {
+			p.drop[k] = Item{Key: k, Reason: reason, AssetID: a.ID}
+		}
+	}
+
+	var orders []models.Order
+	if err := gc.DB.Select("id", "asset_id", "print_file_key").Find(&orders).Error; err != nil {
+		return err
+	}
+	for _, o := range orders {
+		if o.AssetID != nil && expired[*o.AssetID] {
+			if o.PrintFileKey != "" {
+				p.drop[o.PrintFileKey] = Item{Key: o.PrintFileKey, Reason: ReasonRetention, AssetID: *o.AssetID}
+			}
+			continue // the print file goes with the image
+		}
+		p.liveOrder[o.ID.String()] = true
+		if o.PrintFileKey != "" {
+			p.keep[o.PrintFileKey] = true
+		}
+	}
+
+	gc.abortExpiredUploads(ctx, r)
+
+	if r.DryRun {
+		r.AssetsRemoved, r.AssetsPurged = len(p.remove), len(p.purge)
+	} else if err := gc.applyDB(&p, r); err != nil {
+		return err
+	}
+
+	prefixes := []string{uploadsPrefix, mockupsPrefix, rendersPrefix}
+	if gc.Quarantine > 0 {
+		prefixes = append(prefixes, quarantinePrefix)
+	}
+	for _, prefix := range prefixes {
+		objs, err := gc.Store.List(ctx, prefix)
+		if err != nil {
+			r.fail("listing %s: %v", prefix, err)
+			continue
+		}
+		for _, o := range objs {
+			r.Scanned++
+			before := cutoff
+			if prefix == quarantinePrefix {
+				before = quarantineCutoff
+			}
+			it, ok := gc.classify(&p, prefix, o, before)
+			if !ok {
+				continue
+			}
+			if !r.DryRun {
+				if err := gc.Store.Delete(ctx, o.Key); err != nil {
+					r.fail("deleting %s: %v", o.Key, err)
+					continue
+				}
+			}
+			r.add(it)
+		}
+	}
+	return nil
+}
+
+// applyDB updates the database before any object is deleted, so a failed
+// delete only ever leaves an orphan for the next run. An asset that was put
+// in an order since planning is kept.
+func (gc *Collector) applyDB(p *plan, r *Report) error {
+	if len(p.remove) > 0 {
+		var removed []models.Asset
+		err := gc.DB.Clauses(clause.Returning{Columns: []clause.Column{{Name: "id"}}}).
+			Where("id IN ? AND NOT EXISTS (SELECT 1 FROM orders WHERE orders.asset_id = assets.id)", p.remove).
+			Delete(&removed).Error
+		if err != nil {
+			return err
+		}
+		r.AssetsRemoved = len(removed)
+		gone := map[uuid.UUID]bool{}
+		for _, a := range removed {
+			gone[a.ID] = true
+		}
+		for _, id := range p.remove {
+			if !gone[id] {
+				p.revive(id)
+			}
+		}
+	}
+
+	if len(p.purge) > 0 {
+		err := gc.DB.Transaction(func(tx *gorm.DB) error {
+			var purged []models.Asset
+			err := tx.Model(&purged).Clauses(clause.Returning{Columns: []clause.Column{{Name: "id"}}}).
+				Where("id IN ? AND NOT EXISTS (SELECT 1 FROM orders WHERE orders.asset_id = assets.id AND (LOWER(orders.status) <> LOWER(?) OR orders.delivered_at IS NULL OR orders.delivered_at >= ?))",
+					p.purge, models.OrderDelivered, r.StartedAt.Add(-gc.Retention)).
+				Updates(map[string]any{
+					"status": models.AssetPurged, "purged_at": r.StartedAt,
+					"thumb_key": "", "medium_key": "", "preview_key": "",
+				}).Error
+			if err != nil {
+				return err
+			}
+			r.AssetsPurged = len(purged)
+			done := map[uuid.UUID]bool{}
+			ids := make([]uuid.UUID, 0, len(purged))
+			for _, a := range purged {
+				done[a.ID] = true
+				ids = append(ids, a.ID)
+			}
+			for _, id := range p.purge {
+				if !done[id] {
+					p.revive(id)
+				}
+			}
+			if len(ids) == 0 {
+				return nil
+			}
+			return tx.Model(&models.Order{}).Where("asset_id IN ?", ids).Update("print_file_key", "").Error
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// abortExpiredUploads discards the parts of multipart uploads that were never
+// completed. Their assets stay pending and are removed after the grace period.
+func (gc *Collector) abortExpiredUploads(ctx context.Context, r *Report) {
+	var expired []models.MultipartUpload
+	if err := gc.DB.Where("status = ? AND expires_at < ?", models.MultipartActive, r.StartedAt).Find(&expired).Error; err != nil {
+		r.fail("listing expired uploads: %v", err)
+		return
+	}
+	for _, mu := range expired {
+		if !r.DryRun {
+			if err := gc.Store.AbortMultipart(ctx, mu.ObjectKey, mu.UploadID); err != nil && !errors.Is(err, storage.ErrNotFound) {
+				r.fail("aborting upload %s: %v", mu.ID, err)
+				continue
+			}
+			if err := gc.DB.Model(&mu).Update("status", models.MultipartAborted).Error; err != nil {
+				r.fail("aborting upload %s: %v", mu.ID, err)
+				continue
+			}
+		}
+		r.UploadsAborted++
+	}
+}
+
+// revive moves a dropped asset's objects back to the keep set.
+func (p *plan) revive(id uuid.UUID) {
+	delete(p.dropped, id)
+	p.liveAsset[id] = true
+	for k, it := range p.drop {
+		if it.AssetID == id {
+			delete(p.drop, k)
+			p.keep[k] = true
+		}
+	}
+}
+
+// classify decides whether o should go. Objects nothing knows about are only
+// collected once older than cutoff: the grace period, since an upload may be
+// in flight, or the quarantine period under quarantinePrefix.
+func (gc *Collector) classify(p *plan, prefix string, o storage.ObjectInfo, cutoff time.Time) (Item, bool) {
+	if it, ok := p.drop[o.Key]; ok {
+		it.Size = o.Size
+		return it, true
+	}
+	if p.keep[o.Key] {
+		return Item{}, false
+	}
+
+	item := Item{Key: o.Key, Size: o.Size, Reason: ReasonOrphan}
+	parts := strings.Split(strings.TrimPrefix(o.Key, prefix), "/")
+	switch prefix {
+	case mockupsPrefix:
+		if len(parts) >= 2 {
+			if id, err := uuid.Parse(parts[1]); err == nil {
+				if p.liveAsset[id] {
+					return Item{}, false
+				}
+				if reason, ok := p.dropped[id]; ok {
+					item.Reason, item.AssetID = reason, id
+					return item, true
+				}
+			}
+		}
+	case rendersPrefix:
+		if p.liveOrder[parts[0]] {
+			return Item{}, false
+		}
+	}
+	return item, o.LastModified.Before(cutoff)
+}
+
+// assetKeys lists every object an asset may own, including variants the
+// thumbnail worker is still writing.
+func assetKeys(a *models.Asset) []string {
+	keys := []string{a.ObjectKey}
+	for _, v := range thumbs.Variants {
+		keys = append(keys, thumbs.VariantKey(a.ObjectKey, v.Name))
+	}
+	for _, k := range []string{a.ThumbKey, a.MediumKey, a.PreviewKey, a.QuarantineKey} {
+		if k != "" {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
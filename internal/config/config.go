@@ -4,21 +4,75 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
+	// DevMode (APP_ENV=development) allows shortcuts that are unsafe in
+	// production, such as a throwaway JWT signing key
+	DevMode bool
+
 	DatabaseURL string
 	JWTSecret   string
 	JWTExpiresH int
 
+	// Asymmetric signing keys: one <kid>.pem per key in JWTKeysDir
+	JWTKeysDir      string
+	JWTActiveKID    string
+	JWTAcceptLegacy bool // still verify old HS256 tokens signed with JWTSecret
+
+	TOTPIssuer string
+
+	// OpenID Connect sign-in; a provider is enabled when it has client IDs
+	GoogleClientIDs []string
+	GoogleIssuer    string
+	GoogleJWKSURL   string
+	AppleClientIDs  []string
+	AppleIssuer     string
+	AppleJWKSURL    string
+
+	// Reverse proxies allowed to set X-Forwarded-For; empty trusts none, so
+	// the client IP used for login lockout is the connecting address
+	TrustedProxies []string
+
+	LoginMaxFailures   int
+	LoginMaxIPFailures int
+	LoginLockoutMin    int
+
+	// "s3" (default) or "local" to keep objects on disk under LocalStorageDir
+	StorageBackend     string
+	LocalStorageDir    string
+	LocalStorageSecret string // signs local upload/download URLs
+	PublicBaseURL      string // where clients reach this API, for local signed URLs
+
 	S3Endpoint  string
 	S3UseSSL    bool
 	S3AccessKey string
 	S3SecretKey string
 	S3Bucket    string
 	S3Region    string
+	S3URLTTLMin int // lifetime of presigned view URLs
+
+	UploadMaxMB    int
+	MultipartMaxMB int // resumable uploads, for large TIFFs
+	ImageMaxPixels int // largest image decoded for thumbnails, mockups and print files
+
+	// Malware scanning on finalize: "none" (default) or "clamav". clamd's
+	// StreamMaxLength must be at least the largest upload.
+	Scanner       string
+	ClamdAddr     string
+	ClamdTimeoutS int
+
+	// Storage cleanup: unordered uploads are deleted after the grace period,
+	// delivered orders' images after the retention period and quarantined
+	// uploads after the quarantine period (0 keeps them)
+	CleanupIntervalH int // 0 (the default) disables scheduled runs
+	CleanupDryRun    bool
+	UploadGraceH     int
+	RetentionDays    int
+	QuarantineDays   int
 
 	SMTPHost  string
 	SMTPPort  int
@@ -37,6 +91,21 @@ func Load() *Config {
 		}
 		return i
 	}
+	toList := func(k string) []string {
+		var out []string
+		for _, v := range strings.Split(os.Getenv(k), ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				out = append(out, v)
+			}
+		}
+		return out
+	}
+	orDefault := func(k, def string) string {
+		if v := os.Getenv(k); v != "" {
+			return v
+		}
+		return def
+	}
 	toBool := func(k string, def bool) bool {
 		v := os.Getenv(k)
 		if v == "" {
@@ -46,16 +115,54 @@ func Load() *Config {
 	}
 
 	cfg := &Config{
+		DevMode:     os.Getenv("APP_ENV") == "development",
 		DatabaseURL: os.Getenv("DATABASE_URL"),
 		JWTSecret:   os.Getenv("JWT_SECRET"),
 		JWTExpiresH: toInt("JWT_EXPIRES_HOURS", 720),
 
+		JWTKeysDir:      os.Getenv("JWT_KEYS_DIR"),
+		JWTActiveKID:    os.Getenv("JWT_ACTIVE_KID"),
+		JWTAcceptLegacy: toBool("JWT_ACCEPT_LEGACY_HS256", false),
+		TOTPIssuer:      os.Getenv("TOTP_ISSUER"),
+
+		GoogleClientIDs: toList("OIDC_GOOGLE_CLIENT_IDS"),
+		GoogleIssuer:    orDefault("OIDC_GOOGLE_ISSUER", "https://accounts.google.com"),
+		GoogleJWKSURL:   orDefault("OIDC_GOOGLE_JWKS_URL", "https://www.googleapis.com/oauth2/v3/certs"),
+		AppleClientIDs:  toList("OIDC_APPLE_CLIENT_IDS"),
+		AppleIssuer:     orDefault("OIDC_APPLE_ISSUER", "https://appleid.apple.com"),
+		AppleJWKSURL:    orDefault("OIDC_APPLE_JWKS_URL", "https://appleid.apple.com/auth/keys"),
+
+		TrustedProxies:     toList("TRUSTED_PROXIES"),
+		LoginMaxFailures:   toInt("LOGIN_MAX_FAILURES", 5),
+		LoginMaxIPFailures: toInt("LOGIN_MAX_IP_FAILURES", 50),
+		LoginLockoutMin:    toInt("LOGIN_LOCKOUT_MINUTES", 15),
+
+		StorageBackend:     orDefault("STORAGE_BACKEND", "s3"),
+		LocalStorageDir:    orDefault("LOCAL_STORAGE_DIR", "./data/storage"),
+		LocalStorageSecret: os.Getenv("LOCAL_STORAGE_SECRET"),
+		PublicBaseURL:      orDefault("PUBLIC_BASE_URL", "http://localhost:8080"),
+
 		S3Endpoint:  os.Getenv("S3_ENDPOINT"),
 		S3UseSSL:    toBool("S3_USE_SSL", false),
 		S3AccessKey: os.Getenv("S3_ACCESS_KEY"),
 		S3SecretKey: os.Getenv("S3_SECRET_KEY"),
 		S3Bucket:    os.Getenv("S3_BUCKET"),
 		S3Region:    os.Getenv("S3_REGION"),
+		S3URLTTLMin: toInt("S3_URL_TTL_MINUTES", 15),
+
+		UploadMaxMB:    toInt("UPLOAD_MAX_MB", 50),
+		MultipartMaxMB: toInt("UPLOAD_MULTIPART_MAX_MB", 500),
+		ImageMaxPixels: toInt("IMAGE_MAX_MEGAPIXELS", 200) * 1_000_000,
+
+		Scanner:       orDefault("UPLOAD_SCANNER", "none"),
+		ClamdAddr:     orDefault("CLAMD_ADDR", "localhost:3310"),
+		ClamdTimeoutS: toInt("CLAMD_TIMEOUT_SECONDS", 120),
+
+		CleanupIntervalH: toInt("CLEANUP_INTERVAL_HOURS", 0),
+		CleanupDryRun:    toBool("CLEANUP_DRY_RUN", false),
+		UploadGraceH:     toInt("UPLOAD_GRACE_HOURS", 72),
+		RetentionDays:    toInt("DELIVERED_RETENTION_DAYS", 0),
+		QuarantineDays:   toInt("QUARANTINE_RETENTION_DAYS", 30),
 
 		FromEmail: os.Getenv("SMTP_FROM_EMAIL"),
 		SMTPHost:  os.Getenv("SMTP_HOST"),
@@ -63,7 +170,19 @@ func Load() *Config {
 		SMTPUser:  os.Getenv("SMTP_USER"),
 		SMTPPass:  os.Getenv("SMTP_PASS"),
 	}
-	if cfg.DatabaseURL == "" || cfg.JWTSecret == "" {
+	if cfg.TOTPIssuer == "" {
+		cfg.TOTPIssuer = "FrameLane"
+	}
+	if cfg.JWTAcceptLegacy && cfg.JWTSecret == "" {
+		log.Fatal("JWT_ACCEPT_LEGACY_HS256 needs JWT_SECRET")
+	}
+	if cfg.StorageBackend != "s3" && cfg.StorageBackend != "local" {
+		log.Fatalf("STORAGE_BACKEND must be s3 or local, got %q", cfg.StorageBackend)
+	}
+	if cfg.Scanner != "none" && cfg.Scanner != "clamav" {
+		log.Fatalf("UPLOAD_SCANNER must be none or clamav, got %q", cfg.Scanner)
+	}
+	if cfg.DatabaseURL == "" {
 		log.Fatal("Missing critical env vars")
 	}
 	return cfg
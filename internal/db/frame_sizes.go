@@ -0,0 +1,73 @@
+package db
+
+import (
+	"log"
+	"sort"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/olamideolayemi/framelane-api/internal/models"
+)
+
+// BackfillFrameSizes fills in dimensions for sizes created when only the name
+// ("A4 (8x12 in)", "21x37 in") recorded them, and orders sizes by area the
+// first time, when none has a display order yet. Sizes whose name can't be
+// parsed are left alone.
+func BackfillFrameSizes(db *gorm.DB) error {
+	var sizes []models.FrameSize
+	if err := db.Where("width IS NULL OR width = 0 OR height IS NULL OR height = 0").Find(&sizes).Error; err != nil {
+		return err
+	}
+	for _, s := range sizes {
+		w, h, unit, ok := models.ParseSizeName(s.Name)
+		if !ok {
+			log.Printf("frame size %q: no dimensions in name, set them by hand", s.Name)
+			continue
+		}
+		s.SetDimensions(w, h, unit)
+		err := db.Model(&s).Select("width", "height", "unit", "orientation").Updates(&s).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	// Once sizes are ordered a 0 is the admin's choice, not a missing value
+	var ordered int64
+	if err := db.Unscoped().Model(&models.FrameSize{}).Where("display_order <> 0").Count(&ordered).Error; err != nil || ordered > 0 {
+		return err
+	}
+	var all []models.FrameSize
+	if err := db.Where("custom = ?", false).Find(&all).Error; err != nil {
+		return err
+	}
+	sort.SliceStable(all, func(i, j int) bool { return area(all[i]) < area(all[j]) })
+	for i, s := range all {
+		if err := db.Model(&s).Update("display_order", (i+1)*10).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func area(s models.FrameSize) float64 {
+	w, h, _ := s.Dimensions()
+	return w * h
+}
+
+// BackfillPriceHistory gives sizes without price versions, whether from before
+// prices were versioned or newly seeded, an initial one at their current price.
+func BackfillPriceHistory(db *gorm.DB) error {
+	var sizes []models.FrameSize
+	if err := db.Where("NOT EXISTS (SELECT 1 FROM price_versions v WHERE v.size_id = frame_sizes.id)").Find(&sizes).Error; err != nil {
+		return err
+	}
+	for _, s := range sizes {
+		from := s.CreatedAt
+		v := models.PriceVersion{ID: uuid.New(), SizeID: s.ID, Price: s.Price, EffectiveFrom: from, ActivatedAt: &from, Note: "initial price"}
+		if err := db.Create(&v).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
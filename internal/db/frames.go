@@ -0,0 +1,40 @@
+package db
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"github.com/olamideolayemi/framelane-api/internal/models"
+)
+
+// BackfillFrameSlugs gives frames created before slugs existed, or holding a
+// slug that has since been reserved, one derived from their name, numbered
+// when two names slugify the same.
+func BackfillFrameSlugs(db *gorm.DB) error {
+	var frames []models.Frame
+	if err := db.Unscoped().Where("slug IS NULL OR slug = '' OR slug IN ?", models.ReservedSlugs).Order("created_at").Find(&frames).Error; err != nil {
+		return err
+	}
+	for _, f := range frames {
+		base := models.DefaultSlug(f.Name)
+		if base == "" {
+			base = "frame"
+		}
+		slug := base
+		for n := 2; ; n++ {
+			var count int64
+			if err := db.Unscoped().Model(&models.Frame{}).Where("slug = ?", slug).Count(&count).Error; err != nil {
+				return err
+			}
+			if count == 0 {
+				break
+			}
+			slug = fmt.Sprintf("%s-%d", base, n)
+		}
+		if err := db.Unscoped().Model(&f).Update("slug", slug).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,15 @@
+package db
+
+import (
+	"gorm.io/gorm"
+
+	"github.com/olamideolayemi/framelane-api/internal/models"
+)
+
+// BackfillDeliveredAt dates orders delivered before the delivery time was
+// recorded from their last update, the closest record there is.
+func BackfillDeliveredAt(db *gorm.DB) error {
+	return db.Model(&models.Order{}).
+		Where("LOWER(status) = LOWER(?) AND delivered_at IS NULL", models.OrderDelivered).
+		UpdateColumn("delivered_at", gorm.Expr("updated_at")).Error
+}
@@ -0,0 +1,280 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/olamideolayemi/framelane-api/internal/auth"
+	"github.com/olamideolayemi/framelane-api/internal/models"
+)
+
+type APIKeysHandler struct {
+	DB *gorm.DB
+}
+
+var validScopes = map[string]bool{
+	models.ScopeOrdersRead:  true,
+	models.ScopeOrdersWrite: true,
+}
+
+// APIKeyResponse is the admin view of a key; the secret is only shown on create/rotate
+type APIKeyResponse struct {
+	ID          uuid.UUID  `json:"id"`
+	Name        string     `json:"name"`
+	Prefix      string     `json:"prefix"`
+	UserID      uuid.UUID  `json:"userId"`
+	Scopes      []string   `json:"scopes"`
+	RateLimit   int        `json:"rateLimit"`
+	LastUsedAt  *time.Time `json:"lastUsedAt"`
+	ExpiresAt   *time.Time `json:"expiresAt"`
+	RevokedAt   *time.Time `json:"revokedAt"`
+	RotatedFrom *uuid.UUID `json:"rotatedFrom,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+}
+
+func toAPIKeyResponse(k *models.APIKey) APIKeyResponse {
+	return APIKeyResponse{
+		ID:          k.ID,
+		Name:        k.Name,
+		Prefix:      k.Prefix,
+		UserID:      k.UserID,
+		Scopes:      k.ScopeList(),
+		RateLimit:   k.RateLimit,
+		LastUsedAt:  k.LastUsedAt,
+		ExpiresAt:   k.ExpiresAt,
+		RevokedAt:   k.RevokedAt,
+		RotatedFrom: k.RotatedFrom,
+		CreatedAt:   k.CreatedAt,
+	}
+}
+
+func normalizeScopes(in []string) (string, bool) {
+	seen := map[string]bool{}
+	var out []string
+	for _, s := range in {
+		s = strings.TrimSpace(s)
+		if !validScopes[s] {
+			return "", false
+		}
+		if !seen[s] {
+			seen[s] = true
+			out = append(out, s)
+		}
+	}
+	return strings.Join(out, ","), true
+}
+
+// POST /v1/admin/api-keys (admin)
+func (h *APIKeysHandler) Create(c *gin.Context) {
+	var in struct {
+		Name      string     `json:"name" binding:"required"`
+		UserID    string     `json:"userId" binding:"required"`
+		Scopes    []string   `json:"scopes" binding:"required"`
+		RateLimit *int       `json:"rateLimit"`
+		ExpiresAt *time.Time `json:"expiresAt"`
+	}
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	uid, err := uuid.Parse(in.UserID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+	var user models.User
+	if err := h.DB.First(&user, "id = ?", uid).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "User not found"})
+		return
+	}
+	if user.IsAdmin {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "api keys can't act as admin accounts"})
+		return
+	}
+
+	scopes, ok := normalizeScopes(in.Scopes)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unknown scope"})
+		return
+	}
+
+	plain, prefix, hash, err := auth.GenerateAPIKey()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate key"})
+		return
+	}
+	key := models.APIKey{
+		ID:        uuid.New(),
+		Name:      in.Name,
+		Prefix:    prefix,
+		KeyHash:   hash,
+		UserID:    uid,
+		Scopes:    scopes,
+		RateLimit: 60,
+		ExpiresAt: in.ExpiresAt,
+	}
+	if in.RateLimit != nil {
+		key.RateLimit = *in.RateLimit
+	}
+
+	if err := h.DB.Create(&key).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create api key"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"key": plain, "apiKey": toAPIKeyResponse(&key)})
+}
+
+// GET /v1/admin/api-keys (admin)
+func (h *APIKeysHandler) List(c *gin.Context) {
+	q := h.DB.Order("created_at DESC")
+	if uid := c.Query("userId"); uid != "" {
+		q = q.Where("user_id = ?", uid)
+	}
+	if c.Query("includeRevoked") != "true" {
+		q = q.Where("revoked_at IS NULL")
+	}
+
+	var keys []models.APIKey
+	if err := q.Find(&keys).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	response := make([]APIKeyResponse, len(keys))
+	for i := range keys {
+		response[i] = toAPIKeyResponse(&keys[i])
+	}
+	c.JSON(http.StatusOK, gin.H{"apiKeys": response})
+}
+
+func (h *APIKeysHandler) find(c *gin.Context) (*models.APIKey, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid api key ID"})
+		return nil, false
+	}
+	var k models.APIKey
+	if err := h.DB.First(&k, "id = ?", id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "api key not found"})
+		return nil, false
+	}
+	return &k, true
+}
+
+// PATCH /v1/admin/api-keys/:id (admin) -> change name, scopes, rate limit or expiry
+func (h *APIKeysHandler) Update(c *gin.Context) {
+	k, ok := h.find(c)
+	if !ok {
+		return
+	}
+	var in struct {
+		Name      string     `json:"name,omitempty"`
+		Scopes    []string   `json:"scopes,omitempty"`
+		RateLimit *int       `json:"rateLimit,omitempty"`
+		ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	}
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	if in.Name != "" {
+		k.Name = in.Name
+	}
+	if in.Scopes != nil {
+		scopes, valid := normalizeScopes(in.Scopes)
+		if !valid {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unknown scope"})
+			return
+		}
+		k.Scopes = scopes
+	}
+	if in.RateLimit != nil {
+		k.RateLimit = *in.RateLimit
+	}
+	if in.ExpiresAt != nil {
+		k.ExpiresAt = in.ExpiresAt
+	}
+
+	if err := h.DB.Save(k).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update api key"})
+		return
+	}
+	c.JSON(http.StatusOK, toAPIKeyResponse(k))
+}
+
+// POST /v1/admin/api-keys/:id/rotate (admin) -> new secret with the same settings.
+// The old key keeps working for graceHours (default 0) so partners can switch over.
+func (h *APIKeysHandler) Rotate(c *gin.Context) {
+	old, ok := h.find(c)
+	if !ok {
+		return
+	}
+	if old.RevokedAt != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "api key already revoked"})
+		return
+	}
+	var in struct {
+		GraceHours int `json:"graceHours"`
+	}
+	_ = c.ShouldBindJSON(&in)
+
+	plain, prefix, hash, err := auth.GenerateAPIKey()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate key"})
+		return
+	}
+	oldID := old.ID
+	next := models.APIKey{
+		ID:          uuid.New(),
+		Name:        old.Name,
+		Prefix:      prefix,
+		KeyHash:     hash,
+		UserID:      old.UserID,
+		Scopes:      old.Scopes,
+		RateLimit:   old.RateLimit,
+		ExpiresAt:   old.ExpiresAt,
+		RotatedFrom: &oldID,
+	}
+
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&next).Error; err != nil {
+			return err
+		}
+		if in.GraceHours > 0 {
+			until := time.Now().Add(time.Duration(in.GraceHours) * time.Hour)
+			if old.ExpiresAt != nil && old.ExpiresAt.Before(until) {
+				return nil // already expires sooner than the grace period
+			}
+			return tx.Model(old).Update("expires_at", until).Error
+		}
+		return tx.Model(old).Update("revoked_at", time.Now()).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to rotate api key"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"key": plain, "apiKey": toAPIKeyResponse(&next)})
+}
+
+// DELETE /v1/admin/api-keys/:id (admin) -> revoke immediately
+func (h *APIKeysHandler) Revoke(c *gin.Context) {
+	k, ok := h.find(c)
+	if !ok {
+		return
+	}
+	if k.RevokedAt == nil {
+		if err := h.DB.Model(k).Update("revoked_at", time.Now()).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to revoke api key"})
+			return
+		}
+	}
+	c.Status(http.StatusNoContent)
+}
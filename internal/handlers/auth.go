@@ -1,21 +1,34 @@
 package handlers
 
 import (
+	"fmt"
+	"log"
+	"math"
 	// "net/http"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 
 	"github.com/gin-gonic/gin"
 	"github.com/olamideolayemi/framelane-api/internal/auth"
+	"github.com/olamideolayemi/framelane-api/internal/email"
 	"github.com/olamideolayemi/framelane-api/internal/models"
 )
 
+// challengeTTL bounds how long a user has to enter their 2FA code after the password step.
+const challengeTTL = 5 * time.Minute
+
 type AuthHandler struct {
-	DB        *gorm.DB
-	JWTSecret string
-	JWTHours  int
+	DB       *gorm.DB
+	Keys     *auth.KeySet
+	JWTHours int
+	Throttle *auth.Throttle // optional; nil disables lockout
+	Email    *email.Sender
+	OIDC     map[string]*auth.OIDCProvider // keyed by provider name, e.g. "google"
 }
 
 func (h *AuthHandler) Register(c *gin.Context) {
@@ -42,7 +55,7 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
-	t, _ := auth.MakeToken(h.JWTSecret, u.ID.String(), u.IsAdmin, h.JWTHours)
+	t, _ := auth.MakeToken(h.Keys, u.ID.String(), u.IsAdmin, false, h.JWTHours)
 	c.JSON(201, gin.H{
 		"token": t,
 		"user": gin.H{
@@ -64,26 +77,156 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	}
 	in.Email = strings.ToLower(strings.TrimSpace(in.Email))
 
+	if !h.allowAttempt(c, in.Email) {
+		return
+	}
+
 	var u models.User
 	if err := h.DB.Where("email = ?", in.Email).First(&u).Error; err != nil {
-		c.JSON(401, gin.H{"error": "invalid creds"})
+		// Burn the same bcrypt time as a real check so timing doesn't reveal unknown emails
+		_ = bcrypt.CompareHashAndPassword(dummyPasswordHash(), []byte(in.Password))
+		h.loginFailed(c, in.Email, nil)
 		return
 	}
 	if bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(in.Password)) != nil {
+		h.loginFailed(c, in.Email, &u)
+		return
+	}
+
+	// Second step required: hand back a challenge instead of a session
+	if u.TOTPEnabled {
+		ct, err := auth.MakeChallengeToken(h.Keys, u.ID.String(), challengeTTL)
+		if err != nil {
+			c.JSON(500, gin.H{"error": "could not start two-factor login"})
+			return
+		}
+		c.JSON(200, gin.H{"mfaRequired": true, "challengeToken": ct})
+		return
+	}
+
+	h.loginSucceeded(in.Email)
+	h.issueSession(c, &u, false)
+}
+
+// POST /v1/auth/login/2fa -> exchange a challenge token and TOTP/recovery code for a session
+func (h *AuthHandler) LoginTwoFactor(c *gin.Context) {
+	var in struct {
+		ChallengeToken string `json:"challengeToken"`
+		Code           string `json:"code"`
+	}
+	if err := c.BindJSON(&in); err != nil || in.ChallengeToken == "" || in.Code == "" {
+		c.JSON(400, gin.H{"error": "bad input"})
+		return
+	}
+
+	uid, err := auth.ParseChallengeToken(h.Keys, in.ChallengeToken)
+	if err != nil {
+		c.JSON(401, gin.H{"error": "challenge expired, sign in again"})
+		return
+	}
+
+	var u models.User
+	if err := h.DB.First(&u, "id = ?", uid).Error; err != nil || !u.TOTPEnabled {
 		c.JSON(401, gin.H{"error": "invalid creds"})
 		return
 	}
+	if !h.allowAttempt(c, u.Email) {
+		return
+	}
+	if !verifySecondFactor(h.DB, &u, in.Code) {
+		h.loginFailed(c, u.Email, &u)
+		return
+	}
+
+	h.loginSucceeded(u.Email)
+	h.issueSession(c, &u, true)
+}
+
+var (
+	dummyHashOnce sync.Once
+	dummyHash     []byte
+)
+
+// dummyPasswordHash is compared against when the email is unknown. Same cost
+// as Register so both paths take the same time.
+func dummyPasswordHash() []byte {
+	dummyHashOnce.Do(func() {
+		dummyHash, _ = bcrypt.GenerateFromPassword([]byte("framelane-dummy-password"), 12)
+	})
+	return dummyHash
+}
+
+// allowAttempt rejects the request with 429 while the account or IP is backing off or locked.
+func (h *AuthHandler) allowAttempt(c *gin.Context, emailAddr string) bool {
+	if h.Throttle == nil {
+		return true
+	}
+	wait, err := h.Throttle.Wait(emailAddr, c.ClientIP())
+	if err != nil {
+		log.Printf("login throttle check failed: %v", err)
+		return true
+	}
+	if wait > 0 {
+		secs := int(math.Ceil(wait.Seconds()))
+		c.Header("Retry-After", strconv.Itoa(secs))
+		c.JSON(429, gin.H{"error": "too many failed attempts, try again later", "retryAfter": secs})
+		return false
+	}
+	return true
+}
+
+// loginFailed records the failure and answers 401. u is nil for unknown emails.
+func (h *AuthHandler) loginFailed(c *gin.Context, emailAddr string, u *models.User) {
+	if h.Throttle != nil {
+		lockedUntil, err := h.Throttle.RecordFailure(emailAddr, c.ClientIP())
+		if err != nil {
+			log.Printf("login throttle update failed: %v", err)
+		}
+		if lockedUntil != nil && u != nil && h.Email != nil {
+			data := map[string]string{
+				"CustomerName": u.Name,
+				"LockedUntil":  lockedUntil.UTC().Format("Jan 2, 2006 15:04 MST"),
+				"IP":           c.ClientIP(),
+				"Year":         fmt.Sprintf("%d", time.Now().Year()),
+			}
+			if err := SendAccountLocked(h.Email, u.Email, data); err != nil {
+				log.Printf("Error sending account locked email: %v", err)
+			}
+		}
+	}
+	c.JSON(401, gin.H{"error": "invalid creds"})
+}
+
+func (h *AuthHandler) loginSucceeded(emailAddr string) {
+	if h.Throttle == nil {
+		return
+	}
+	if err := h.Throttle.RecordSuccess(emailAddr); err != nil {
+		log.Printf("login throttle reset failed: %v", err)
+	}
+}
+
+func SendAccountLocked(sender *email.Sender, customerEmail string, data map[string]string) error {
+	subject := "Your FrameLane sign-in has been temporarily locked"
+	htmlBody, err := email.ParseTemplate("account_locked.html", data)
+	if err != nil {
+		return err
+	}
+	return sender.Send(customerEmail, subject, htmlBody)
+}
 
-	t, _ := auth.MakeToken(h.JWTSecret, u.ID.String(), u.IsAdmin, h.JWTHours)
+func (h *AuthHandler) issueSession(c *gin.Context, u *models.User, mfa bool) {
+	t, _ := auth.MakeToken(h.Keys, u.ID.String(), u.IsAdmin, mfa, h.JWTHours)
 	c.JSON(200, gin.H{
 		"token": t,
 		"user": gin.H{
-			"id":      u.ID.String(),
-			"name":    u.Name,
-			"email":   u.Email,
-			"phone":   u.Phone,
-			"address": u.Address,
-			"isAdmin": u.IsAdmin,
+			"id":               u.ID.String(),
+			"name":             u.Name,
+			"email":            u.Email,
+			"phone":            u.Phone,
+			"address":          u.Address,
+			"isAdmin":          u.IsAdmin,
+			"twoFactorEnabled": u.TOTPEnabled,
 		},
 	})
 }
@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/olamideolayemi/framelane-api/internal/catalog"
+	"github.com/olamideolayemi/framelane-api/internal/models"
+)
+
+const maxCatalogCSVBytes = 2 << 20
+
+type CatalogHandler struct {
+	DB *gorm.DB
+}
+
+// GET /v1/admin/catalog/export?type=sizes|frames (admin) -> CSV download
+func (h *CatalogHandler) Export(c *gin.Context) {
+	var buf bytes.Buffer
+	var err error
+	switch kind := c.DefaultQuery("type", "sizes"); kind {
+	case "sizes":
+		var sizes []models.FrameSize
+		if err = h.DB.Where("custom = ?", false).Order("display_order ASC, price ASC").Find(&sizes).Error; err == nil {
+			err = catalog.ExportSizes(&buf, sizes)
+		}
+	case "frames":
+		var frames []models.Frame
+		if err = h.DB.Order("display_order ASC, name ASC").Find(&frames).Error; err == nil {
+			err = catalog.ExportFrames(&buf, frames)
+		}
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "type must be sizes or frames"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to export catalog"})
+		return
+	}
+	name := fmt.Sprintf("framelane-%s-%s.csv", c.DefaultQuery("type", "sizes"), time.Now().Format("2006-01-02"))
+	c.Header("Content-Disposition", `attachment; filename="`+name+`"`)
+	c.Data(http.StatusOK, "text/csv; charset=utf-8", buf.Bytes())
+}
+
+var (
+	errImportInvalid = errors.New("import has errors")
+	errPlanChanged   = errors.New("import plan changed")
+)
+
+// POST /v1/admin/catalog/import?type=sizes|frames&dryRun=false&planHash= (admin, CSV body or multipart "file")
+// -> the creates, updates and deactivations the file makes. Nothing is
+// written unless dryRun=false, and then all of it in one transaction. Applying
+// takes the planHash of the dry run and is refused if the plan has changed.
+func (h *CatalogHandler) Import(c *gin.Context) {
+	kind := c.DefaultQuery("type", "sizes")
+	if kind != "sizes" && kind != "frames" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "type must be sizes or frames"})
+		return
+	}
+	data, ok := readCatalogCSV(c)
+	if !ok {
+		return
+	}
+	dryRun := c.Query("dryRun") != "false"
+	planHash := c.Query("planHash")
+	if !dryRun && planHash == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "planHash from a dry run is required to apply an import"})
+		return
+	}
+
+	plan := func(db *gorm.DB) (*catalog.Plan, error) {
+		if kind == "frames" {
+			return catalog.PlanFrames(db, bytes.NewReader(data))
+		}
+		return catalog.PlanSizes(db, bytes.NewReader(data), adminID(c))
+	}
+
+	if dryRun {
+		p, err := plan(h.DB)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"dryRun": true, "type": kind, "plan": p, "planHash": p.Hash()})
+		return
+	}
+
+	// Re-plan inside the transaction so what's applied matches the catalogue as it is now
+	var p *catalog.Plan
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if p, err = plan(tx); err != nil {
+			return err
+		}
+		if len(p.Errors) > 0 {
+			return errImportInvalid
+		}
+		if p.Hash() != planHash {
+			return errPlanChanged
+		}
+		return p.Apply(tx)
+	})
+	switch {
+	case errors.Is(err, errPlanChanged):
+		c.JSON(http.StatusConflict, gin.H{"error": "the catalogue or file changed since the dry run; review the new plan and apply it again", "plan": p, "planHash": p.Hash()})
+	case errors.Is(err, errImportInvalid):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "fix the listed rows and import again; nothing was changed", "plan": p})
+	case err != nil && p == nil:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "import failed; nothing was changed"})
+	default:
+		c.JSON(http.StatusOK, gin.H{"dryRun": false, "type": kind, "plan": p})
+	}
+}
+
+func readCatalogCSV(c *gin.Context) ([]byte, bool) {
+	var r io.Reader = c.Request.Body
+	if fh, err := c.FormFile("file"); err == nil {
+		f, err := fh.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "could not read file"})
+			return nil, false
+		}
+		defer f.Close()
+		r = f
+	}
+	data, err := io.ReadAll(io.LimitReader(r, maxCatalogCSVBytes+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "could not read file"})
+		return nil, false
+	}
+	if len(data) > maxCatalogCSVBytes {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "CSV must be under 2 MB"})
+		return nil, false
+	}
+	return data, true
+}
@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/olamideolayemi/framelane-api/internal/cleanup"
+)
+
+type CleanupHandler struct {
+	GC *cleanup.Collector
+}
+
+// POST /v1/admin/storage/cleanup?dryRun=false (admin) -> run the collector now.
+// Dry run unless dryRun=false is passed explicitly.
+func (h *CleanupHandler) Run(c *gin.Context) {
+	dryRun := c.Query("dryRun") != "false"
+	r, err := h.GC.Run(c, dryRun)
+	if errors.Is(err, cleanup.ErrRunning) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "cleanup failed", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, r)
+}
+
+// GET /v1/admin/storage/cleanup (admin) -> report of the last run
+func (h *CleanupHandler) Last(c *gin.Context) {
+	r := h.GC.Last()
+	if r == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no cleanup has run since the server started"})
+		return
+	}
+	c.JSON(http.StatusOK, r)
+}
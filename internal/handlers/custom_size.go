@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/olamideolayemi/framelane-api/internal/models"
+)
+
+// POST /v1/orders/custom-size-quotes (auth) {frameId, width, height, unit?} -> priced quote that can be ordered until it expires
+func (h *OrdersHandler) QuoteCustomSize(c *gin.Context) {
+	uid, err := uuid.Parse(c.GetString("uid"))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var in struct {
+		FrameID uuid.UUID `json:"frameId" binding:"required"`
+		Width   float64   `json:"width" binding:"required"`
+		Height  float64   `json:"height" binding:"required"`
+		Unit    string    `json:"unit"`
+	}
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data", "details": err.Error()})
+		return
+	}
+	if in.Unit == "" {
+		in.Unit = models.UnitInch
+	}
+	if !models.ValidUnit(in.Unit) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unit must be in, cm or mm"})
+		return
+	}
+
+	var frame models.Frame
+	if err := h.DB.First(&frame, "id = ?", in.FrameID).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Frame not found"})
+		return
+	}
+	if frame.Status != "available" {
+		c.JSON(http.StatusConflict, gin.H{"error": "This frame is out of stock"})
+		return
+	}
+
+	pricing, err := loadCustomSizePricing(h.DB)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not load pricing"})
+		return
+	}
+	size := models.FrameSize{Width: in.Width, Height: in.Height, Unit: in.Unit}
+	wIn, hIn, _ := size.Dimensions()
+	if in.Width <= 0 || in.Height <= 0 || min(wIn, hIn) < pricing.MinDimensionIn || max(wIn, hIn) > pricing.MaxDimensionIn {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":          fmt.Sprintf("Each side must be between %g and %g inches", pricing.MinDimensionIn, pricing.MaxDimensionIn),
+			"minDimensionIn": pricing.MinDimensionIn,
+			"maxDimensionIn": pricing.MaxDimensionIn,
+		})
+		return
+	}
+
+	price := pricing.Price(wIn, hIn)
+	quote := models.CustomSizeQuote{
+		ID:        uuid.New(),
+		UserID:    uid,
+		FrameID:   frame.ID,
+		Width:     in.Width,
+		Height:    in.Height,
+		Unit:      in.Unit,
+		WidthIn:   wIn,
+		HeightIn:  hIn,
+		Price:     price.Total,
+		ExpiresAt: time.Now().Add(time.Duration(pricing.QuoteTTLHours) * time.Hour),
+	}
+	if err := h.DB.Create(&quote).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not save quote"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"quote": quote, "breakdown": price})
+}
+
+// GET /v1/orders/custom-size-quotes/:id (auth)
+func (h *OrdersHandler) GetCustomSizeQuote(c *gin.Context) {
+	var quote models.CustomSizeQuote
+	if err := h.DB.First(&quote, "id = ? AND user_id = ?", c.Param("id"), c.GetString("uid")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Quote not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"quote": quote, "expired": quote.Expired(time.Now()), "ordered": quote.OrderID != nil})
+}
+
+// loadOpenQuote loads a caller's quote that can still be ordered.
+func (h *OrdersHandler) loadOpenQuote(c *gin.Context, uid uuid.UUID, quoteIDStr string) (*models.CustomSizeQuote, bool) {
+	var quote models.CustomSizeQuote
+	if err := h.DB.First(&quote, "id = ? AND user_id = ?", quoteIDStr, uid).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Quote not found"})
+		return nil, false
+	}
+	if quote.OrderID != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Quote has already been ordered"})
+		return nil, false
+	}
+	if quote.Expired(time.Now()) {
+		c.JSON(http.StatusGone, gin.H{"error": "Quote has expired, request a new one"})
+		return nil, false
+	}
+	return &quote, true
+}
+
+// quoteSize returns the hidden FrameSize for a quote, creating it the first
+// time the quote is ordered so retries reuse it.
+func quoteSize(db *gorm.DB, quote *models.CustomSizeQuote) (uuid.UUID, error) {
+	if quote.SizeID != nil {
+		return *quote.SizeID, nil
+	}
+	size := models.FrameSize{
+		ID:     uuid.New(),
+		Name:   fmt.Sprintf("Custom %gx%g %s (%s)", quote.Width, quote.Height, quote.Unit, quote.ID.String()[:8]),
+		Price:  quote.Price,
+		Status: "available",
+		Custom: true,
+	}
+	size.SetDimensions(quote.Width, quote.Height, quote.Unit)
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&size).Error; err != nil {
+			return err
+		}
+		return tx.Model(quote).Update("size_id", size.ID).Error
+	})
+	return size.ID, err
+}
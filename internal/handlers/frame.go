@@ -1,35 +1,144 @@
 package handlers
 
 import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"math"
 	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 
+	"github.com/olamideolayemi/framelane-api/internal/imaging"
+	"github.com/olamideolayemi/framelane-api/internal/inventory"
 	"github.com/olamideolayemi/framelane-api/internal/models"
+	"github.com/olamideolayemi/framelane-api/internal/storage"
 )
 
+const maxFrameImageBytes = 10 << 20
+
 type FrameHandler struct {
-	DB *gorm.DB
+	DB        *gorm.DB
+	Store     storage.Store // product images
+	Inventory *inventory.Service
 }
 
-// List all frame sizes (user & admin)
+// defaultAspectTolerance is how far a size's aspect ratio may differ from the
+// photo's (relative) and still be listed as compatible.
+const defaultAspectTolerance = 0.03
+
+// List all frame sizes (user & admin). inStock is for the given frame, or
+// any available frame.
+// GET /v1/frames/size?sort=price|area|order&orientation=&aspect=|imageWidth=&imageHeight=&tolerance=&frameId=
 func (h *FrameHandler) ListFrameSizes(c *gin.Context) {
-	var frames []models.FrameSize
-	if err := h.DB.Order("price ASC").Find(&frames).Error; err != nil {
+	var sizes []models.FrameSize
+	if err := h.DB.Where("custom = ?", false).Order("price ASC").Find(&sizes).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch frames"})
 		return
 	}
-	c.JSON(http.StatusOK, frames)
+
+	// Photo aspect ratio, given directly or as pixel dimensions
+	aspect := 0.0
+	if v := c.Query("aspect"); v != "" {
+		a, err := strconv.ParseFloat(v, 64)
+		if err != nil || a <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "aspect must be a positive number"})
+			return
+		}
+		aspect = a
+	} else if c.Query("imageWidth") != "" || c.Query("imageHeight") != "" {
+		w, err1 := strconv.Atoi(c.Query("imageWidth"))
+		ht, err2 := strconv.Atoi(c.Query("imageHeight"))
+		if err1 != nil || err2 != nil || w <= 0 || ht <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "imageWidth and imageHeight must be positive integers"})
+			return
+		}
+		aspect = float64(w) / float64(ht)
+	}
+	if aspect > 0 && aspect < 1 {
+		aspect = 1 / aspect // compare long side over short side
+	}
+	tolerance := defaultAspectTolerance
+	if v := c.Query("tolerance"); v != "" {
+		t, err := strconv.ParseFloat(v, 64)
+		if err != nil || t < 0 || t > 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "tolerance must be between 0 and 1"})
+			return
+		}
+		tolerance = t
+	}
+	orientation := c.Query("orientation")
+	if orientation != "" && orientation != models.Portrait && orientation != models.Landscape && orientation != models.Square {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "orientation must be portrait, landscape or square"})
+		return
+	}
+	frameIDs, ok := h.stockIDs(c, "frameId", h.DB.Model(&models.Frame{}))
+	if !ok {
+		return
+	}
+	stock, err := h.Inventory.Stock()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check stock"})
+		return
+	}
+
+	out := make([]models.FrameSizeResponse, 0, len(sizes))
+	for _, s := range sizes {
+		r := s.Response()
+		inStock := stock.AnyInStock(frameIDs, []uuid.UUID{s.ID})
+		r.InStock = &inStock
+		if orientation != "" && s.Orientation != orientation {
+			continue
+		}
+		if aspect > 0 && (r.AspectRatio == 0 || math.Abs(r.AspectRatio-aspect)/aspect > tolerance) {
+			continue
+		}
+		out = append(out, r)
+	}
+
+	switch c.DefaultQuery("sort", "price") {
+	case "price":
+		// already in price order
+	case "area":
+		sort.SliceStable(out, func(i, j int) bool { return out[i].AreaSqIn < out[j].AreaSqIn })
+	case "order":
+		sort.SliceStable(out, func(i, j int) bool { return out[i].DisplayOrder < out[j].DisplayOrder })
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "sort must be price, area or order"})
+		return
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+// sizeDimensions validates explicit dimensions, or parses them from the name
+// when none are given.
+func sizeDimensions(name string, width, height float64, unit string) (float64, float64, string, bool) {
+	if width == 0 && height == 0 {
+		return models.ParseSizeName(name)
+	}
+	if unit == "" {
+		unit = models.UnitInch
+	}
+	return width, height, unit, width > 0 && height > 0 && models.ValidUnit(unit)
 }
 
 // Admin: Create a new frame size
 func (h *FrameHandler) CreateFrameSize(c *gin.Context) {
 	type CreateFrameRequest struct {
-		Name   string `json:"name" binding:"required"`
-		Price  int    `json:"price" binding:"required"`
-		Status string `json:"status"` // optional, default to available
+		Name         string  `json:"name" binding:"required"`
+		Price        int     `json:"price" binding:"required"`
+		Status       string  `json:"status"` // optional, default to available
+		Width        float64 `json:"width"`  // optional, parsed from name when omitted
+		Height       float64 `json:"height"`
+		Unit         string  `json:"unit"`         // in (default), cm or mm
+		DisplayOrder *int    `json:"displayOrder"` // optional, after the last size by default
 	}
 
 	var req CreateFrameRequest
@@ -39,8 +148,12 @@ func (h *FrameHandler) CreateFrameSize(c *gin.Context) {
 	}
 
 	var existing models.FrameSize
-	if err := h.DB.Where("name = ?", req.Name).First(&existing).Error; err == nil {
-		c.JSON(http.StatusConflict, gin.H{"error": "frame size already exists"})
+	if err := h.DB.Unscoped().Where("name = ?", req.Name).First(&existing).Error; err == nil {
+		msg := "frame size already exists"
+		if existing.DeletedAt.Valid {
+			msg = "an archived frame size has this name, restore it instead"
+		}
+		c.JSON(http.StatusConflict, gin.H{"error": msg})
 		return
 	}
 
@@ -51,14 +164,32 @@ func (h *FrameHandler) CreateFrameSize(c *gin.Context) {
 		return
 	}
 
+	w, ht, unit, ok := sizeDimensions(req.Name, req.Width, req.Height, req.Unit)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "give width, height and unit (in, cm or mm), or a name like '16x20 in'"})
+		return
+	}
+
+	order, err := models.NextSizeOrder(h.DB)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create frame"})
+		return
+	}
+	if req.DisplayOrder != nil {
+		order = *req.DisplayOrder
+	}
+
 	frame := models.FrameSize{
-		ID:     uuid.New(),
-		Name:   req.Name,
-		Price:  req.Price,
-		Status: req.Status,
+		ID:           uuid.New(),
+		Name:         req.Name,
+		Price:        req.Price,
+		Status:       req.Status,
+		DisplayOrder: order,
 	}
+	frame.SetDimensions(w, ht, unit)
 
-	if err := h.DB.Create(&frame).Error; err != nil {
+	create := func(tx *gorm.DB) error { return tx.Create(&frame).Error }
+	if err := h.recordPrice(c, create, &frame, "initial price"); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create frame"})
 		return
 	}
@@ -83,9 +214,13 @@ func (h *FrameHandler) UpdateFrameSize(c *gin.Context) {
 
 	// Request payload struct
 	type UpdateFrameRequest struct {
-		Name   string `json:"name,omitempty"`
-		Price  *int   `json:"price,omitempty"`  // pointer to detect if sent
-		Status string `json:"status,omitempty"` // "available" or "out_of_stock"
+		Name         string   `json:"name,omitempty"`
+		Price        *int     `json:"price,omitempty"`  // pointer to detect if sent
+		Status       string   `json:"status,omitempty"` // "available" or "out_of_stock"
+		Width        *float64 `json:"width,omitempty"`
+		Height       *float64 `json:"height,omitempty"`
+		Unit         string   `json:"unit,omitempty"`
+		DisplayOrder *int     `json:"displayOrder,omitempty"`
 	}
 
 	var req UpdateFrameRequest
@@ -97,6 +232,7 @@ func (h *FrameHandler) UpdateFrameSize(c *gin.Context) {
 	if req.Name != "" {
 		frame.Name = req.Name
 	}
+	priceChanged := req.Price != nil && *req.Price != frame.Price
 	if req.Price != nil {
 		frame.Price = *req.Price
 	}
@@ -107,8 +243,34 @@ func (h *FrameHandler) UpdateFrameSize(c *gin.Context) {
 		}
 		frame.Status = req.Status
 	}
+	if req.Width != nil || req.Height != nil || req.Unit != "" {
+		w, ht, unit := frame.Width, frame.Height, frame.Unit
+		if req.Width != nil {
+			w = *req.Width
+		}
+		if req.Height != nil {
+			ht = *req.Height
+		}
+		if req.Unit != "" {
+			unit = req.Unit
+		}
+		if w <= 0 || ht <= 0 || !models.ValidUnit(unit) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "width and height must be positive and unit one of in, cm, mm"})
+			return
+		}
+		frame.SetDimensions(w, ht, unit)
+	}
+	if req.DisplayOrder != nil {
+		frame.DisplayOrder = *req.DisplayOrder
+	}
 
-	if err := h.DB.Save(&frame).Error; err != nil {
+	save := func(tx *gorm.DB) error { return tx.Save(&frame).Error }
+	if priceChanged {
+		err = h.recordPrice(c, save, &frame, "price edited")
+	} else {
+		err = save(h.DB)
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update frame"})
 		return
 	}
@@ -116,7 +278,8 @@ func (h *FrameHandler) UpdateFrameSize(c *gin.Context) {
 	c.JSON(http.StatusOK, frame)
 }
 
-// Admin: Delete a frame size
+// Admin: Archive a frame size. It leaves the catalogue and can't be ordered,
+// but existing orders still show it; restore it from the archive.
 func (h *FrameHandler) DeleteFrameSize(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := uuid.Parse(idStr)
@@ -126,47 +289,246 @@ func (h *FrameHandler) DeleteFrameSize(c *gin.Context) {
 	}
 
 	if err := h.DB.Delete(&models.FrameSize{}, "id = ?", id).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete frame"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to archive frame size"})
 		return
 	}
 
 	c.Status(http.StatusNoContent)
 }
 
-// List all frame types
+// GET /v1/admin/frames/size/archived (admin) -> archived sizes, most recent first
+func (h *FrameHandler) ListArchivedSizes(c *gin.Context) {
+	var sizes []models.FrameSize
+	if err := h.DB.Unscoped().Where("deleted_at IS NOT NULL").Order("deleted_at DESC").Find(&sizes).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch archived sizes"})
+		return
+	}
+	out := make([]models.FrameSizeResponse, len(sizes))
+	for i, s := range sizes {
+		out[i] = s.Response()
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+// POST /v1/admin/frames/size/:id/restore (admin) -> put an archived size back in the catalogue
+func (h *FrameHandler) RestoreFrameSize(c *gin.Context) {
+	var size models.FrameSize
+	if err := h.DB.Unscoped().Where("deleted_at IS NOT NULL").First(&size, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "archived frame size not found"})
+		return
+	}
+	if err := h.DB.Unscoped().Model(&models.FrameSize{}).Where("id = ?", size.ID).Update("deleted_at", nil).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to restore frame size"})
+		return
+	}
+	size.DeletedAt = gorm.DeletedAt{}
+	c.JSON(http.StatusOK, size.Response())
+}
+
+// frameResponse adds view URLs for the frame's gallery images.
+func (h *FrameHandler) frameResponse(c *gin.Context, f *models.Frame) models.FrameResponse {
+	r := models.FrameResponse{
+		ID:              f.ID,
+		Name:            f.Name,
+		Slug:            f.Slug,
+		Status:          f.Status,
+		Description:     f.Description,
+		Material:        f.Material,
+		Color:           f.Color,
+		MouldingWidthIn: f.MouldingWidthIn,
+		DisplayOrder:    f.DisplayOrder,
+		Images:          make([]models.FrameImageResponse, 0, len(f.Images)),
+		ArchivedAt:      models.ArchivedAt(f.DeletedAt),
+	}
+	for _, img := range f.Images {
+		r.Images = append(r.Images, models.FrameImageResponse{
+			ID:       img.ID,
+			URL:      h.Store.ViewURL(c, img.Key),
+			Alt:      img.Alt,
+			Position: img.Position,
+		})
+	}
+	return r
+}
+
+func preloadImages(db *gorm.DB) *gorm.DB {
+	return db.Order("position ASC, created_at ASC")
+}
+
+// stockIDs returns the frame or size given by the param query, or else the
+// IDs of every available row of q, to check catalogue stock against.
+func (h *FrameHandler) stockIDs(c *gin.Context, param string, q *gorm.DB) ([]uuid.UUID, bool) {
+	if v := c.Query(param); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + param})
+			return nil, false
+		}
+		return []uuid.UUID{id}, true
+	}
+	var ids []uuid.UUID
+	if err := q.Where("status = ?", "available").Pluck("id", &ids).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check stock"})
+		return nil, false
+	}
+	return ids, true
+}
+
+// withStock sets inStock on frame responses, for the given size or any
+// available one.
+func (h *FrameHandler) withStock(c *gin.Context, out []models.FrameResponse) bool {
+	sizeIDs, ok := h.stockIDs(c, "sizeId", h.DB.Model(&models.FrameSize{}).Where("custom = ?", false))
+	if !ok {
+		return false
+	}
+	stock, err := h.Inventory.Stock()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check stock"})
+		return false
+	}
+	for i := range out {
+		inStock := stock.AnyInStock([]uuid.UUID{out[i].ID}, sizeIDs)
+		out[i].InStock = &inStock
+	}
+	return true
+}
+
+// List all frame types. inStock is for the given size, or any available size.
+// GET /v1/frames?material=&color=&sizeId=
 func (h *FrameHandler) ListFrameTypes(c *gin.Context) {
+	q := h.DB.Preload("Images", preloadImages).Order("display_order ASC, name ASC")
+	if v := c.Query("material"); v != "" {
+		q = q.Where("LOWER(material) = LOWER(?)", v)
+	}
+	if v := c.Query("color"); v != "" {
+		q = q.Where("LOWER(color) = LOWER(?)", v)
+	}
+
 	var frames []models.Frame
-	if err := h.DB.Order("name ASC").Find(&frames).Error; err != nil {
+	if err := q.Find(&frames).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch frame types"})
 		return
 	}
-	c.JSON(http.StatusOK, frames)
+	out := make([]models.FrameResponse, len(frames))
+	for i := range frames {
+		out[i] = h.frameResponse(c, &frames[i])
+	}
+	if !h.withStock(c, out) {
+		return
+	}
+	c.JSON(http.StatusOK, out)
 }
 
-// Admin: Create a new frame type
-func (h *FrameHandler) CreateFrameType(c *gin.Context) {
-	type Request struct {
-		Name   string `json:"name" binding:"required"`
-		Status string `json:"status"` // optional
+// GET /v1/frames/:slug?sizeId= -> one frame by slug or ID
+func (h *FrameHandler) GetFrameType(c *gin.Context) {
+	key := c.Param("slug")
+	q := h.DB.Preload("Images", preloadImages)
+	if id, err := uuid.Parse(key); err == nil {
+		q = q.Where("id = ?", id)
+	} else {
+		q = q.Where("slug = ?", key)
 	}
+	var frame models.Frame
+	if err := q.First(&frame).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "frame type not found"})
+		return
+	}
+	out := []models.FrameResponse{h.frameResponse(c, &frame)}
+	if !h.withStock(c, out) {
+		return
+	}
+	c.JSON(http.StatusOK, out[0])
+}
 
-	var req Request
-	if err := c.ShouldBindJSON(&req); err != nil {
+// frameTypeRequest is the body of frame type create and update. Pointers
+// tell an update which fields were sent.
+type frameTypeRequest struct {
+	Name            *string  `json:"name"`
+	Slug            *string  `json:"slug"`
+	Status          *string  `json:"status"`
+	Description     *string  `json:"description"`
+	Material        *string  `json:"material"`
+	Color           *string  `json:"color"`
+	MouldingWidthIn *float64 `json:"mouldingWidthIn"`
+	DisplayOrder    *int     `json:"displayOrder"`
+}
+
+// apply copies the sent fields onto f, returning a message for invalid input.
+func (req *frameTypeRequest) apply(f *models.Frame) string {
+	if req.Name != nil {
+		if strings.TrimSpace(*req.Name) == "" {
+			return "name cannot be empty"
+		}
+		f.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Slug != nil {
+		if slices.Contains(models.ReservedSlugs, *req.Slug) {
+			return "slug " + *req.Slug + " is reserved, choose another"
+		}
+		if !models.ValidSlug(*req.Slug) {
+			return "slug may only contain lowercase letters, digits and dashes"
+		}
+		f.Slug = *req.Slug
+	}
+	if req.Status != nil {
+		if *req.Status != "available" && *req.Status != "out_of_stock" {
+			return "status must be 'available' or 'out_of_stock'"
+		}
+		f.Status = *req.Status
+	}
+	if req.Description != nil {
+		f.Description = *req.Description
+	}
+	if req.Material != nil {
+		f.Material = strings.TrimSpace(*req.Material)
+	}
+	if req.Color != nil {
+		f.Color = strings.TrimSpace(*req.Color)
+	}
+	if req.MouldingWidthIn != nil {
+		if *req.MouldingWidthIn < 0 || *req.MouldingWidthIn > 6 {
+			return "mouldingWidthIn must be between 0 and 6"
+		}
+		f.MouldingWidthIn = *req.MouldingWidthIn
+	}
+	if req.DisplayOrder != nil {
+		f.DisplayOrder = *req.DisplayOrder
+	}
+	return ""
+}
+
+// slugTaken reports whether another frame, archived or not, already uses slug.
+func (h *FrameHandler) slugTaken(slug string, self uuid.UUID) bool {
+	var count int64
+	h.DB.Unscoped().Model(&models.Frame{}).Where("slug = ? AND id <> ?", slug, self).Count(&count)
+	return count > 0
+}
+
+// Admin: Create a new frame type
+func (h *FrameHandler) CreateFrameType(c *gin.Context) {
+	var req frameTypeRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Name == nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
 		return
 	}
 
-	if req.Status == "" {
-		req.Status = "available"
-	} else if req.Status != "available" && req.Status != "out_of_stock" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be 'available' or 'out_of_stock'"})
+	frame := models.Frame{ID: uuid.New(), Status: "available"}
+	if msg := req.apply(&frame); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
 		return
 	}
-
-	frame := models.Frame{
-		ID:     uuid.New(),
-		Name:   req.Name,
-		Status: req.Status,
+	if frame.Slug == "" {
+		frame.Slug = models.DefaultSlug(frame.Name)
+	}
+	if frame.Slug == "" || h.slugTaken(frame.Slug, frame.ID) {
+		c.JSON(http.StatusConflict, gin.H{"error": "slug already in use, send a different one"})
+		return
+	}
+	var archived int64
+	h.DB.Unscoped().Model(&models.Frame{}).Where("name = ? AND deleted_at IS NOT NULL", frame.Name).Count(&archived)
+	if archived > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "an archived frame type has this name, restore it instead"})
+		return
 	}
 
 	if err := h.DB.Create(&frame).Error; err != nil {
@@ -174,7 +536,7 @@ func (h *FrameHandler) CreateFrameType(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusCreated, frame)
+	c.JSON(http.StatusCreated, h.frameResponse(c, &frame))
 }
 
 // Admin: Update a frame type
@@ -187,42 +549,35 @@ func (h *FrameHandler) UpdateFrameType(c *gin.Context) {
 	}
 
 	var frame models.Frame
-	if err := h.DB.First(&frame, "id = ?", id).Error; err != nil {
+	if err := h.DB.Preload("Images", preloadImages).First(&frame, "id = ?", id).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "frame type not found"})
 		return
 	}
 
-	type Request struct {
-		Name   string `json:"name,omitempty"`
-		Status string `json:"status,omitempty"`
-	}
-
-	var req Request
+	var req frameTypeRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
 		return
 	}
-
-	if req.Name != "" {
-		frame.Name = req.Name
+	if msg := req.apply(&frame); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
 	}
-	if req.Status != "" {
-		if req.Status != "available" && req.Status != "out_of_stock" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "status must be 'available' or 'out_of_stock'"})
-			return
-		}
-		frame.Status = req.Status
+	if req.Slug != nil && h.slugTaken(frame.Slug, frame.ID) {
+		c.JSON(http.StatusConflict, gin.H{"error": "slug already in use"})
+		return
 	}
 
-	if err := h.DB.Save(&frame).Error; err != nil {
+	if err := h.DB.Omit("Images").Save(&frame).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update frame type"})
 		return
 	}
 
-	c.JSON(http.StatusOK, frame)
+	c.JSON(http.StatusOK, h.frameResponse(c, &frame))
 }
 
-// Admin: Delete a frame type
+// Admin: Archive a frame type. Its images are kept so it can be restored, and
+// existing orders still show it.
 func (h *FrameHandler) DeleteFrameType(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := uuid.Parse(idStr)
@@ -232,9 +587,150 @@ func (h *FrameHandler) DeleteFrameType(c *gin.Context) {
 	}
 
 	if err := h.DB.Delete(&models.Frame{}, "id = ?", id).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete frame type"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to archive frame type"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GET /v1/admin/frames/archived (admin) -> archived frames, most recent first
+func (h *FrameHandler) ListArchivedFrames(c *gin.Context) {
+	var frames []models.Frame
+	if err := h.DB.Unscoped().Preload("Images", preloadImages).
+		Where("deleted_at IS NOT NULL").Order("deleted_at DESC").Find(&frames).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch archived frames"})
+		return
+	}
+	out := make([]models.FrameResponse, len(frames))
+	for i := range frames {
+		out[i] = h.frameResponse(c, &frames[i])
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+// POST /v1/admin/frames/:id/restore (admin) -> put an archived frame back in the catalogue
+func (h *FrameHandler) RestoreFrameType(c *gin.Context) {
+	var frame models.Frame
+	if err := h.DB.Unscoped().Preload("Images", preloadImages).
+		Where("deleted_at IS NOT NULL").First(&frame, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "archived frame type not found"})
+		return
+	}
+	if err := h.DB.Unscoped().Model(&models.Frame{}).Where("id = ?", frame.ID).Update("deleted_at", nil).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to restore frame type"})
+		return
+	}
+	frame.DeletedAt = gorm.DeletedAt{}
+	c.JSON(http.StatusOK, h.frameResponse(c, &frame))
+}
+
+// POST /v1/admin/frames/:id/images (admin, multipart) -> image file, alt, position
+func (h *FrameHandler) AddFrameImage(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid frame ID"})
+		return
+	}
+	var frame models.Frame
+	if err := h.DB.First(&frame, "id = ?", id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "frame type not found"})
+		return
+	}
+
+	fh, err := c.FormFile("image")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "image file is required"})
+		return
+	}
+	if fh.Size > maxFrameImageBytes {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "image must be under 10 MB"})
+		return
+	}
+	f, err := fh.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "could not read image"})
+		return
+	}
+	data, err := io.ReadAll(f)
+	f.Close()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "could not read image"})
+		return
+	}
+	mime := imaging.Sniff(data)
+	ext, ok := allowedImageTypes[mime]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "image must be a JPEG, PNG, WebP or TIFF"})
 		return
 	}
 
+	img := models.FrameImage{ID: uuid.New(), FrameID: frame.ID, Alt: c.PostForm("alt")}
+	if v := c.PostForm("position"); v != "" {
+		if img.Position, err = strconv.Atoi(v); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "position must be a number"})
+			return
+		}
+	} else {
+		var last int
+		h.DB.Model(&models.FrameImage{}).Where("frame_id = ?", frame.ID).Select("COALESCE(MAX(position), 0)").Scan(&last)
+		img.Position = last + 1
+	}
+	img.Key = fmt.Sprintf("frames/%s/images/%s%s", frame.ID, img.ID, ext)
+
+	if err := h.Store.Put(c, img.Key, bytes.NewReader(data), int64(len(data)), mime); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to store image"})
+		return
+	}
+	if err := h.DB.Create(&img).Error; err != nil {
+		_ = h.Store.Delete(c, img.Key)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save image"})
+		return
+	}
+	c.JSON(http.StatusCreated, models.FrameImageResponse{ID: img.ID, URL: h.Store.ViewURL(c, img.Key), Alt: img.Alt, Position: img.Position})
+}
+
+// PATCH /v1/admin/frames/:id/images/:imageId (admin) {alt?, position?}
+func (h *FrameHandler) UpdateFrameImage(c *gin.Context) {
+	var img models.FrameImage
+	if err := h.DB.First(&img, "id = ? AND frame_id = ?", c.Param("imageId"), c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "image not found"})
+		return
+	}
+	var req struct {
+		Alt      *string `json:"alt"`
+		Position *int    `json:"position"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+	if req.Alt != nil {
+		img.Alt = *req.Alt
+	}
+	if req.Position != nil {
+		img.Position = *req.Position
+	}
+	if err := h.DB.Save(&img).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update image"})
+		return
+	}
+	c.JSON(http.StatusOK, models.FrameImageResponse{ID: img.ID, URL: h.Store.ViewURL(c, img.Key), Alt: img.Alt, Position: img.Position})
+}
+
+// DELETE /v1/admin/frames/:id/images/:imageId (admin)
+func (h *FrameHandler) DeleteFrameImage(c *gin.Context) {
+	var img models.FrameImage
+	if err := h.DB.First(&img, "id = ? AND frame_id = ?", c.Param("imageId"), c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "image not found"})
+		return
+	}
+	if err := h.DB.Delete(&img).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete image"})
+		return
+	}
+	if err := h.Store.Delete(c, img.Key); err != nil {
+		log.Printf("frame %s: delete image %s: %v", img.FrameID, img.Key, err)
+	}
 	c.Status(http.StatusNoContent)
 }
@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/olamideolayemi/framelane-api/internal/inventory"
+	"github.com/olamideolayemi/framelane-api/internal/models"
+)
+
+type InventoryHandler struct {
+	DB        *gorm.DB
+	Inventory *inventory.Service
+}
+
+type stockResponse struct {
+	models.StockItem
+	Frame     string `json:"frame"`
+	Size      string `json:"size"`
+	Available int    `json:"available"`
+	Low       bool   `json:"low"`
+}
+
+func toStockResponse(s *models.StockItem) stockResponse {
+	return stockResponse{StockItem: *s, Frame: s.Frame.Name, Size: s.Size.Name, Available: s.Available(), Low: s.Low()}
+}
+
+// GET /v1/admin/inventory?frameId=&sizeId=&low=true (admin)
+func (h *InventoryHandler) List(c *gin.Context) {
+	q := h.DB.Preload("Frame", models.WithArchived).Preload("Size", models.WithArchived).Order("created_at ASC")
+	if v := c.Query("frameId"); v != "" {
+		q = q.Where("frame_id = ?", v)
+	}
+	if v := c.Query("sizeId"); v != "" {
+		q = q.Where("size_id = ?", v)
+	}
+	if c.Query("low") == "true" {
+		q = q.Where("low_stock_threshold > 0 AND on_hand - reserved <= low_stock_threshold")
+	}
+	var items []models.StockItem
+	if err := q.Find(&items).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch inventory"})
+		return
+	}
+	out := make([]stockResponse, len(items))
+	for i := range items {
+		out[i] = toStockResponse(&items[i])
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+// PUT /v1/admin/inventory (admin) {frameId, sizeId, onHand, lowStockThreshold?} -> start tracking or set a count
+func (h *InventoryHandler) Set(c *gin.Context) {
+	var in struct {
+		FrameID           uuid.UUID `json:"frameId" binding:"required"`
+		SizeID            uuid.UUID `json:"sizeId" binding:"required"`
+		OnHand            *int      `json:"onHand" binding:"required"`
+		LowStockThreshold *int      `json:"lowStockThreshold"`
+	}
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bad input"})
+		return
+	}
+	if *in.OnHand < 0 || (in.LowStockThreshold != nil && *in.LowStockThreshold < 0) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "onHand and lowStockThreshold cannot be negative"})
+		return
+	}
+	var n int64
+	h.DB.Model(&models.Frame{}).Where("id = ?", in.FrameID).Count(&n)
+	if n == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "frame not found"})
+		return
+	}
+	h.DB.Model(&models.FrameSize{}).Where("id = ?", in.SizeID).Count(&n)
+	if n == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "frame size not found"})
+		return
+	}
+
+	item := models.StockItem{ID: uuid.New(), FrameID: in.FrameID, SizeID: in.SizeID, OnHand: *in.OnHand}
+	update := []string{"on_hand", "updated_at"}
+	if in.LowStockThreshold != nil {
+		item.LowStockThreshold = *in.LowStockThreshold
+		update = append(update, "low_stock_threshold")
+	}
+	if err := h.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "frame_id"}, {Name: "size_id"}},
+		DoUpdates: clause.AssignmentColumns(update),
+	}).Create(&item).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save stock"})
+		return
+	}
+	h.respondItem(c, "frame_id = ? AND size_id = ?", in.FrameID, in.SizeID)
+}
+
+// POST /v1/admin/inventory/:id/adjust (admin) {delta} -> add a delivery or write off damaged mouldings
+func (h *InventoryHandler) Adjust(c *gin.Context) {
+	var in struct {
+		Delta int `json:"delta" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "delta is required"})
+		return
+	}
+	res := h.DB.Model(&models.StockItem{}).
+		Where("id = ? AND on_hand + ? >= reserved", c.Param("id"), in.Delta).
+		Update("on_hand", gorm.Expr("on_hand + ?", in.Delta))
+	if res.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to adjust stock"})
+		return
+	}
+	if res.RowsAffected == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "stock item not found, or the adjustment would drop below what is reserved"})
+		return
+	}
+	h.respondItem(c, "id = ?", c.Param("id"))
+}
+
+// respondItem re-reads the stock row, sends any low-stock alert, and returns it.
+func (h *InventoryHandler) respondItem(c *gin.Context, query string, args ...any) {
+	var item models.StockItem
+	if err := h.DB.Where(query, args...).First(&item).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load stock"})
+		return
+	}
+	h.Inventory.Changed(item.ID)
+	h.DB.Preload("Frame", models.WithArchived).Preload("Size", models.WithArchived).First(&item, "id = ?", item.ID)
+	c.JSON(http.StatusOK, toStockResponse(&item))
+}
+
+// DELETE /v1/admin/inventory/:id (admin) -> stop tracking; the frame and size can be ordered without limit again
+func (h *InventoryHandler) Delete(c *gin.Context) {
+	res := h.DB.Delete(&models.StockItem{}, "id = ?", c.Param("id"))
+	if res.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete stock item"})
+		return
+	}
+	if res.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "stock item not found"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
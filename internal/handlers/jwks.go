@@ -0,0 +1,17 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/olamideolayemi/framelane-api/internal/auth"
+)
+
+type KeysHandler struct{ Keys *auth.KeySet }
+
+// GET /.well-known/jwks.json (public) -> verification keys for other services
+func (h *KeysHandler) JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.Keys.JWKS())
+}
@@ -0,0 +1,248 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/olamideolayemi/framelane-api/internal/imaging"
+	"github.com/olamideolayemi/framelane-api/internal/models"
+	"github.com/olamideolayemi/framelane-api/internal/storage"
+)
+
+const (
+	mockupLongSidePx  = 1200
+	maxTextureBytes   = 5 << 20
+	defaultFrameColor = "#3b2a1a"
+)
+
+type MockupHandler struct {
+	DB        *gorm.DB
+	Store     storage.Store
+	MaxPixels int // larger photos are not decoded; 0 means no limit
+}
+
+// loadTemplate returns the frame's template, or the defaults if none was uploaded.
+func loadTemplate(db *gorm.DB, frameID uuid.UUID) (models.FrameTemplate, error) {
+	t := models.FrameTemplate{FrameID: frameID, BorderColor: defaultFrameColor, FrameWidthIn: 1}
+	err := db.First(&t, "frame_id = ?", frameID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return t, nil
+	}
+	return t, err
+}
+
+// GET /v1/mockups?assetId=&frameId=&sizeId=&matColor=&matWidth= (auth) -> framed JPEG preview
+func (h *MockupHandler) Render(c *gin.Context) {
+	uid, _ := c.Get("uid")
+
+	var asset models.Asset
+	if err := h.DB.First(&asset, "id = ? AND user_id = ? AND status = ?", c.Query("assetId"), uid, models.AssetReady).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Image not found"})
+		return
+	}
+	var frame models.Frame
+	if err := h.DB.First(&frame, "id = ?", c.Query("frameId")).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Frame not found"})
+		return
+	}
+	var size models.FrameSize
+	if err := h.DB.First(&size, "id = ?", c.Query("sizeId")).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Frame size not found"})
+		return
+	}
+	printW, printH, ok := size.Dimensions()
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Frame size has no dimensions"})
+		return
+	}
+
+	matWidth := 0.0
+	matColor := imaging.White
+	if v := c.Query("matWidth"); v != "" {
+		w, err := strconv.ParseFloat(v, 64)
+		if err != nil || w < 0 || w > 6 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "matWidth must be between 0 and 6 inches"})
+			return
+		}
+		matWidth = w
+	}
+	if v := c.Query("matColor"); v != "" {
+		col, ok := imaging.ParseHexColor(v)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "matColor must be a hex colour like #ffffff"})
+			return
+		}
+		matColor = col
+	}
+
+	tmpl, err := loadTemplate(h.DB, frame.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not load frame template"})
+		return
+	}
+
+	// Rendered previews are cached per input combination, including the template version
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s|%s|%s|%s|%.2f|%x|%d",
+		asset.ID, frame.ID, size.Name, size.ID, matWidth, matColor, tmpl.UpdatedAt.UnixNano())))
+	cacheKey := fmt.Sprintf("mockups/%s/%s/%s.jpg", asset.UserID, asset.ID, hex.EncodeToString(sum[:8]))
+
+	if st, err := h.Store.Stat(c, cacheKey); err == nil {
+		if obj, err := h.Store.Get(c, cacheKey); err == nil {
+			defer obj.Close()
+			c.Header("X-Mockup-Cache", "hit")
+			c.DataFromReader(http.StatusOK, st.Size, "image/jpeg", obj, nil)
+			return
+		}
+	}
+
+	photo, err := h.loadPhoto(c, &asset)
+	if errors.Is(err, imaging.ErrTooManyPixels) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Image is too large to preview until its thumbnails are ready"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not read image"})
+		return
+	}
+
+	// Hang the print the same way round as the photo
+	pb := photo.Bounds()
+	if (pb.Dx() > pb.Dy()) != (printW > printH) {
+		printW, printH = printH, printW
+	}
+
+	opts := imaging.MockupOptions{
+		PrintWidthIn:  printW,
+		PrintHeightIn: printH,
+		FrameWidthIn:  tmpl.FrameWidthIn,
+		MatWidthIn:    matWidth,
+		MatColor:      matColor,
+		LongSidePx:    mockupLongSidePx,
+	}
+	opts.FrameColor, _ = imaging.ParseHexColor(tmpl.BorderColor)
+	if tmpl.TextureKey != "" {
+		if tex, err := h.loadImage(c, tmpl.TextureKey); err == nil {
+			opts.Texture = tex
+		} else {
+			log.Printf("mockup: frame %s texture: %v", frame.ID, err)
+		}
+	}
+
+	out, err := imaging.EncodeJPEG(imaging.RenderMockup(photo, opts), 85)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not render preview"})
+		return
+	}
+	if err := h.Store.Put(c, cacheKey, bytes.NewReader(out), int64(len(out)), "image/jpeg"); err != nil {
+		log.Printf("mockup: cache write failed: %v", err)
+	}
+
+	c.Header("X-Mockup-Cache", "miss")
+	c.Data(http.StatusOK, "image/jpeg", out)
+}
+
+// loadPhoto prefers the 1600px preview (already upright) over decoding the original.
+func (h *MockupHandler) loadPhoto(c *gin.Context, a *models.Asset) (image.Image, error) {
+	if a.PreviewKey != "" {
+		if img, err := h.loadImage(c, a.PreviewKey); err == nil {
+			return img, nil
+		}
+	}
+	if err := imaging.CheckPixels(a.Width, a.Height, h.MaxPixels); err != nil {
+		return nil, err
+	}
+	return h.loadImage(c, a.ObjectKey)
+}
+
+func (h *MockupHandler) loadImage(c *gin.Context, key string) (image.Image, error) {
+	obj, err := h.Store.Get(c, key)
+	if err != nil {
+		return nil, err
+	}
+	defer obj.Close()
+	return imaging.Decode(obj, h.MaxPixels)
+}
+
+// PUT /v1/admin/frames/:id/template (admin, multipart) -> borderColor, frameWidthIn, texture file
+func (h *MockupHandler) UpdateTemplate(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid frame ID"})
+		return
+	}
+	var frame models.Frame
+	if err := h.DB.First(&frame, "id = ?", id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "frame type not found"})
+		return
+	}
+
+	tmpl, err := loadTemplate(h.DB, frame.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load template"})
+		return
+	}
+
+	if v := c.PostForm("borderColor"); v != "" {
+		if _, ok := imaging.ParseHexColor(v); !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "borderColor must be a hex colour like #3b2a1a"})
+			return
+		}
+		tmpl.BorderColor = v
+	}
+	if v := c.PostForm("frameWidthIn"); v != "" {
+		w, err := strconv.ParseFloat(v, 64)
+		if err != nil || w <= 0 || w > 6 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "frameWidthIn must be between 0 and 6"})
+			return
+		}
+		tmpl.FrameWidthIn = w
+	}
+
+	if fh, err := c.FormFile("texture"); err == nil {
+		if fh.Size > maxTextureBytes {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "texture must be under 5 MB"})
+			return
+		}
+		f, err := fh.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "could not read texture"})
+			return
+		}
+		data, err := io.ReadAll(f)
+		f.Close()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "could not read texture"})
+			return
+		}
+		mime := imaging.Sniff(data)
+		ext, ok := allowedImageTypes[mime]
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "texture must be a JPEG, PNG, WebP or TIFF image"})
+			return
+		}
+		key := fmt.Sprintf("frames/%s/texture-%s%s", frame.ID, uuid.NewString()[:8], ext)
+		if err := h.Store.Put(c, key, bytes.NewReader(data), int64(len(data)), mime); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to store texture"})
+			return
+		}
+		tmpl.TextureKey = key
+	}
+
+	if err := h.DB.Save(&tmpl).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save template"})
+		return
+	}
+	c.JSON(http.StatusOK, tmpl)
+}
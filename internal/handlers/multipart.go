@@ -0,0 +1,324 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/olamideolayemi/framelane-api/internal/models"
+	"github.com/olamideolayemi/framelane-api/internal/storage"
+)
+
+const (
+	defaultPartSize = 8 << 20
+	maxPartSize     = 100 << 20
+	maxPartURLs     = 100            // part URLs handed out per request
+	partURLTTL      = time.Hour      // each part URL
+	multipartTTL    = 24 * time.Hour // the whole upload, after which it is aborted
+)
+
+// POST /v1/uploads/multipart (auth) {contentType, size, partSize?} -> start a resumable upload
+func (h *UploadHandler) StartMultipart(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("uid"))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var in struct {
+		ContentType string `json:"contentType" binding:"required"`
+		Size        int64  `json:"size" binding:"required"`
+		PartSize    int64  `json:"partSize"`
+	}
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bad input"})
+		return
+	}
+	ext, ok := allowedImageTypes[in.ContentType]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "contentType must be one of image/jpeg, image/png, image/webp, image/tiff"})
+		return
+	}
+	if in.Size < 1 || in.Size > h.MultipartMaxBytes {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "size must be between 1 byte and the upload limit", "maxBytes": h.MultipartMaxBytes})
+		return
+	}
+	if in.PartSize == 0 {
+		in.PartSize = defaultPartSize
+	}
+	if in.PartSize < storage.MinPartSize || in.PartSize > maxPartSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "partSize must be between 5 MB and 100 MB"})
+		return
+	}
+	parts := int((in.Size + in.PartSize - 1) / in.PartSize)
+	if parts > storage.MaxParts {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "too many parts, use a larger partSize"})
+		return
+	}
+
+	key := userUploadKey(userID.String(), ext)
+	uploadID, err := h.Store.NewMultipart(c, key, in.ContentType)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not start upload"})
+		return
+	}
+
+	asset := models.Asset{
+		ID:          uuid.New(),
+		UserID:      userID,
+		ObjectKey:   key,
+		ContentType: in.ContentType,
+		Status:      models.AssetPending,
+	}
+	mu := models.MultipartUpload{
+		ID:          uuid.New(),
+		UserID:      userID,
+		AssetID:     asset.ID,
+		ObjectKey:   key,
+		UploadID:    uploadID,
+		ContentType: in.ContentType,
+		Size:        in.Size,
+		PartSize:    in.PartSize,
+		PartCount:   parts,
+		Status:      models.MultipartActive,
+		ExpiresAt:   time.Now().Add(multipartTTL),
+	}
+	if err := h.DB.Create(&asset).Error; err == nil {
+		err = h.DB.Create(&mu).Error
+	}
+	if err != nil {
+		_ = h.Store.AbortMultipart(c, key, uploadID)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not create upload"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, mu)
+}
+
+// findOwnMultipart loads a multipart upload that belongs to the caller.
+func (h *UploadHandler) findOwnMultipart(c *gin.Context) (*models.MultipartUpload, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid upload ID"})
+		return nil, false
+	}
+	var mu models.MultipartUpload
+	if err := h.DB.First(&mu, "id = ? AND user_id = ?", id, c.GetString("uid")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "upload not found"})
+		return nil, false
+	}
+	return &mu, true
+}
+
+// activeMultipart is findOwnMultipart for uploads that can still take parts.
+func (h *UploadHandler) activeMultipart(c *gin.Context) (*models.MultipartUpload, bool) {
+	mu, ok := h.findOwnMultipart(c)
+	if !ok {
+		return nil, false
+	}
+	if mu.Status != models.MultipartActive {
+		c.JSON(http.StatusConflict, gin.H{"error": "upload is " + mu.Status})
+		return nil, false
+	}
+	if time.Now().After(mu.ExpiresAt) {
+		c.JSON(http.StatusGone, gin.H{"error": "upload expired, start a new one"})
+		return nil, false
+	}
+	return mu, true
+}
+
+// missingParts lists part numbers not uploaded yet.
+func missingParts(mu *models.MultipartUpload, done []storage.Part) []int {
+	have := map[int]bool{}
+	for _, p := range done {
+		have[p.Number] = true
+	}
+	missing := []int{}
+	for n := 1; n <= mu.PartCount; n++ {
+		if !have[n] {
+			missing = append(missing, n)
+		}
+	}
+	return missing
+}
+
+// GET /v1/uploads/multipart/:id (auth) -> upload state and the parts already stored, for resuming
+func (h *UploadHandler) GetMultipart(c *gin.Context) {
+	mu, ok := h.findOwnMultipart(c)
+	if !ok {
+		return
+	}
+	resp := gin.H{"upload": mu}
+	if mu.Status == models.MultipartActive {
+		parts, err := h.Store.ListParts(c, mu.ObjectKey, mu.UploadID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "could not read upload state"})
+			return
+		}
+		if parts == nil {
+			parts = []storage.Part{}
+		}
+		resp["parts"] = parts
+		resp["missing"] = missingParts(mu, parts)
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// POST /v1/uploads/multipart/:id/parts (auth) {parts?: [n...]} -> presigned PUT URLs per part.
+// Without a list, URLs for the first missing parts are returned.
+func (h *UploadHandler) PartURLs(c *gin.Context) {
+	mu, ok := h.activeMultipart(c)
+	if !ok {
+		return
+	}
+	var in struct {
+		Parts []int `json:"parts"`
+	}
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&in); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "bad input"})
+			return
+		}
+	}
+
+	if len(in.Parts) == 0 {
+		done, err := h.Store.ListParts(c, mu.ObjectKey, mu.UploadID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "could not read upload state"})
+			return
+		}
+		in.Parts = missingParts(mu, done)
+	}
+	if len(in.Parts) > maxPartURLs {
+		in.Parts = in.Parts[:maxPartURLs]
+	}
+
+	type partURL struct {
+		Number int    `json:"partNumber"`
+		URL    string `json:"url"`
+	}
+	urls := make([]partURL, 0, len(in.Parts))
+	for _, n := range in.Parts {
+		if n < 1 || n > mu.PartCount {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "part number out of range", "partCount": mu.PartCount})
+			return
+		}
+		u, err := h.Store.PresignPart(c, mu.ObjectKey, mu.UploadID, n, partURLTTL)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "could not create part URL"})
+			return
+		}
+		urls = append(urls, partURL{Number: n, URL: u})
+	}
+	c.JSON(http.StatusOK, gin.H{"parts": urls, "expiresAt": time.Now().Add(partURLTTL)})
+}
+
+// POST /v1/uploads/multipart/:id/complete (auth) -> assemble the parts and verify the image
+func (h *UploadHandler) CompleteMultipart(c *gin.Context) {
+	mu, ok := h.findOwnMultipart(c)
+	if !ok {
+		return
+	}
+	var a models.Asset
+	if err := h.DB.First(&a, "id = ?", mu.AssetID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "upload not found"})
+		return
+	}
+
+	// A retry after the parts were assembled only needs the verification
+	if mu.Status == models.MultipartCompleted {
+		if a.Status != models.AssetPending {
+			c.JSON(http.StatusOK, a)
+			return
+		}
+		h.finalize(c, &a, h.MultipartMaxBytes)
+		return
+	}
+	if mu, ok = h.activeMultipart(c); !ok {
+		return
+	}
+
+	parts, err := h.Store.ListParts(c, mu.ObjectKey, mu.UploadID)
+	if errors.Is(err, storage.ErrNotFound) {
+		h.completeAssembled(c, mu, &a)
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not read upload state"})
+		return
+	}
+	if missing := missingParts(mu, parts); len(missing) > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "some parts have not been uploaded", "missing": missing})
+		return
+	}
+	var total int64
+	for _, p := range parts {
+		if p.Number < mu.PartCount && p.Size != mu.PartSize {
+			c.JSON(http.StatusConflict, gin.H{"error": "part has the wrong size, upload it again", "partNumber": p.Number, "partSize": mu.PartSize})
+			return
+		}
+		total += p.Size
+	}
+	if total != mu.Size {
+		c.JSON(http.StatusConflict, gin.H{"error": "uploaded size does not match the declared size", "size": total})
+		return
+	}
+
+	if err := h.Store.CompleteMultipart(c, mu.ObjectKey, mu.UploadID, parts); errors.Is(err, storage.ErrNotFound) {
+		h.completeAssembled(c, mu, &a) // completed by a concurrent request
+		return
+	} else if err != nil {
+		log.Printf("multipart %s: complete: %v", mu.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not assemble upload"})
+		return
+	}
+	if err := h.DB.Model(mu).Update("status", models.MultipartCompleted).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not save upload"})
+		return
+	}
+	h.finalize(c, &a, h.MultipartMaxBytes)
+}
+
+// completeAssembled handles an upload S3 no longer knows about because an
+// earlier complete assembled it but marking it completed failed. If the object
+// is there it is marked completed now and verified as usual.
+func (h *UploadHandler) completeAssembled(c *gin.Context, mu *models.MultipartUpload, a *models.Asset) {
+	st, err := h.Store.Stat(c, mu.ObjectKey)
+	if errors.Is(err, storage.ErrNotFound) || err == nil && st.Size != mu.Size {
+		c.JSON(http.StatusConflict, gin.H{"error": "upload parts are gone, start a new upload"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not read upload state"})
+		return
+	}
+	if err := h.DB.Model(mu).Update("status", models.MultipartCompleted).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not save upload"})
+		return
+	}
+	h.finalize(c, a, h.MultipartMaxBytes)
+}
+
+// DELETE /v1/uploads/multipart/:id (auth) -> abort and discard uploaded parts
+func (h *UploadHandler) AbortMultipart(c *gin.Context) {
+	mu, ok := h.findOwnMultipart(c)
+	if !ok {
+		return
+	}
+	if mu.Status != models.MultipartActive {
+		c.JSON(http.StatusConflict, gin.H{"error": "upload is " + mu.Status})
+		return
+	}
+	if err := h.Store.AbortMultipart(c, mu.ObjectKey, mu.UploadID); err != nil && !errors.Is(err, storage.ErrNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not abort upload"})
+		return
+	}
+	h.DB.Model(mu).Update("status", models.MultipartAborted)
+	h.DB.Model(&models.Asset{}).Where("id = ?", mu.AssetID).
+		Updates(map[string]any{"status": models.AssetRejected, "reject_reason": "upload aborted"})
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+
+	"github.com/olamideolayemi/framelane-api/internal/auth"
+	"github.com/olamideolayemi/framelane-api/internal/models"
+)
+
+var (
+	errEmailNotVerified  = errors.New("email not verified")
+	errLinkNeedsPassword = errors.New("password needed to link account")
+	errLinkBadPassword   = errors.New("wrong password for account to link")
+)
+
+// POST /v1/auth/oidc/:provider -> sign in with a Google/Apple ID token
+func (h *AuthHandler) OIDCLogin(c *gin.Context) {
+	p, ok := h.OIDC[c.Param("provider")]
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "unknown provider"})
+		return
+	}
+
+	var in struct {
+		IDToken  string `json:"idToken" binding:"required"`
+		Nonce    string `json:"nonce"`
+		Name     string `json:"name"`     // Apple only sends the name to the app, on first sign-in
+		Password string `json:"password"` // of an existing password account with this email, to link it
+	}
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bad input"})
+		return
+	}
+
+	claims, err := p.Verify(c, in.IDToken, in.Nonce)
+	if err != nil {
+		log.Printf("oidc %s: %v", p.Name, err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid id token"})
+		return
+	}
+
+	emailAddr := strings.ToLower(strings.TrimSpace(claims.Email))
+	if in.Password != "" && !h.allowAttempt(c, emailAddr) {
+		return
+	}
+	u, err := h.userForIdentity(p.Name, claims, in.Name, in.Password)
+	switch {
+	case errors.Is(err, errEmailNotVerified):
+		c.JSON(http.StatusForbidden, gin.H{"error": "a verified email is required to sign in"})
+		return
+	case errors.Is(err, errLinkNeedsPassword):
+		c.JSON(http.StatusConflict, gin.H{"error": "an account with this email already exists, send its password to link it", "linkRequired": true})
+		return
+	case errors.Is(err, errLinkBadPassword):
+		h.loginFailed(c, emailAddr, nil)
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not sign in"})
+		return
+	}
+	if in.Password != "" {
+		h.loginSucceeded(emailAddr)
+	}
+
+	if u.TOTPEnabled {
+		ct, err := auth.MakeChallengeToken(h.Keys, u.ID.String(), challengeTTL)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "could not start two-factor login"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"mfaRequired": true, "challengeToken": ct})
+		return
+	}
+	h.issueSession(c, u, false)
+}
+
+// userForIdentity finds the user linked to provider+subject. Otherwise it
+// creates a new user, or links the existing one with the same email. Password
+// sign-up never checks the email, so an account whose email isn't verified is
+// only linked with its password; otherwise whoever registered the address
+// first could take over the provider login.
+func (h *AuthHandler) userForIdentity(provider string, claims *auth.IDClaims, name, password string) (*models.User, error) {
+	var u models.User
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		var ident models.UserIdentity
+		err := tx.Where("provider = ? AND subject = ?", provider, claims.Subject).First(&ident).Error
+		if err == nil {
+			return tx.First(&u, "id = ?", ident.UserID).Error
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		emailAddr := strings.ToLower(strings.TrimSpace(claims.Email))
+		if emailAddr == "" || !claims.Verified() {
+			return errEmailNotVerified
+		}
+
+		err = tx.Where("email = ?", emailAddr).First(&u).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			if name == "" {
+				name = claims.Name
+			}
+			// No password: this account can only sign in through the provider
+			// until the user sets one from their profile.
+			u = models.User{Email: emailAddr, Name: name, EmailVerified: true}
+			err = tx.Create(&u).Error
+		} else if err == nil && !u.EmailVerified && u.Password != "" {
+			if password == "" {
+				return errLinkNeedsPassword
+			}
+			if bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(password)) != nil {
+				return errLinkBadPassword
+			}
+			// The provider vouched for the email and the password for the account
+			u.EmailVerified = true
+			err = tx.Model(&u).Update("email_verified", true).Error
+		}
+		if err != nil {
+			return err
+		}
+
+		return tx.Create(&models.UserIdentity{
+			ID:       uuid.New(),
+			UserID:   u.ID,
+			Provider: provider,
+			Subject:  claims.Subject,
+			Email:    emailAddr,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
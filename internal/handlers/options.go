@@ -0,0 +1,404 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/olamideolayemi/framelane-api/internal/models"
+)
+
+type OptionsHandler struct {
+	DB *gorm.DB
+}
+
+func preloadChoices(db *gorm.DB) *gorm.DB {
+	return db.Order("display_order ASC, name ASC")
+}
+
+// GET /v1/options?frameId=&sizeId= -> add-on groups offered for a frame and size
+func (h *OptionsHandler) List(c *gin.Context) {
+	var frameID, sizeID uuid.UUID
+	for param, dst := range map[string]*uuid.UUID{"frameId": &frameID, "sizeId": &sizeID} {
+		if v := c.Query(param); v != "" {
+			id, err := uuid.Parse(v)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + param})
+				return
+			}
+			*dst = id
+		}
+	}
+
+	var groups []models.OptionGroup
+	if err := h.DB.Preload("Choices", func(db *gorm.DB) *gorm.DB {
+		return preloadChoices(db).Where("status = ?", "available")
+	}).Order("display_order ASC, name ASC").Find(&groups).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch options"})
+		return
+	}
+
+	out := []models.OptionGroupResponse{}
+	for _, g := range groups {
+		if frameID != uuid.Nil && !g.AppliesToFrame(frameID) {
+			continue
+		}
+		if sizeID != uuid.Nil && !g.AppliesToSize(sizeID) {
+			continue
+		}
+		if len(g.Choices) == 0 {
+			continue
+		}
+		out = append(out, g.Response())
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+// GET /v1/admin/options (admin) -> every group with all its choices
+func (h *OptionsHandler) AdminList(c *gin.Context) {
+	var groups []models.OptionGroup
+	if err := h.DB.Preload("Choices", preloadChoices).Order("display_order ASC, name ASC").Find(&groups).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch options"})
+		return
+	}
+	out := make([]models.OptionGroupResponse, len(groups))
+	for i := range groups {
+		out[i] = groups[i].Response()
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+// optionGroupRequest is the body of group create and update. Pointers tell an
+// update which fields were sent.
+type optionGroupRequest struct {
+	Name         *string      `json:"name"`
+	Description  *string      `json:"description"`
+	Required     *bool        `json:"required"`
+	MultiSelect  *bool        `json:"multiSelect"`
+	DisplayOrder *int         `json:"displayOrder"`
+	FrameIDs     *[]uuid.UUID `json:"frameIds"`
+	SizeIDs      *[]uuid.UUID `json:"sizeIds"`
+}
+
+// apply copies the sent fields onto g, returning a message for invalid input.
+func (h *OptionsHandler) apply(req *optionGroupRequest, g *models.OptionGroup) string {
+	if req.Name != nil {
+		if strings.TrimSpace(*req.Name) == "" {
+			return "name cannot be empty"
+		}
+		g.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Description != nil {
+		g.Description = *req.Description
+	}
+	if req.Required != nil {
+		g.Required = *req.Required
+	}
+	if req.MultiSelect != nil {
+		g.MultiSelect = *req.MultiSelect
+	}
+	if req.DisplayOrder != nil {
+		g.DisplayOrder = *req.DisplayOrder
+	}
+	if req.FrameIDs != nil {
+		if !h.allExist(&models.Frame{}, *req.FrameIDs) {
+			return "frameIds contains an unknown frame"
+		}
+		g.FrameIDs = models.JoinIDs(*req.FrameIDs)
+	}
+	if req.SizeIDs != nil {
+		if !h.allExist(&models.FrameSize{}, *req.SizeIDs) {
+			return "sizeIds contains an unknown size"
+		}
+		g.SizeIDs = models.JoinIDs(*req.SizeIDs)
+	}
+	return ""
+}
+
+// allExist reports whether every ID names a frame or size. Archived ones count,
+// so groups restricted to them keep working once they are restored.
+func (h *OptionsHandler) allExist(model any, ids []uuid.UUID) bool {
+	if len(ids) == 0 {
+		return true
+	}
+	var count int64
+	models.WithArchived(h.DB).Model(model).Where("id IN ?", ids).Count(&count)
+	return int(count) == len(ids)
+}
+
+// POST /v1/admin/options (admin)
+func (h *OptionsHandler) CreateGroup(c *gin.Context) {
+	var req optionGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Name == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+	g := models.OptionGroup{ID: uuid.New()}
+	if msg := h.apply(&req, &g); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+	if err := h.DB.Create(&g).Error; err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "option group already exists"})
+		return
+	}
+	g.Choices = []models.OptionChoice{}
+	c.JSON(http.StatusCreated, g.Response())
+}
+
+// findGroup loads the group named by :id with its choices.
+func (h *OptionsHandler) findGroup(c *gin.Context) (*models.OptionGroup, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid option group ID"})
+		return nil, false
+	}
+	var g models.OptionGroup
+	if err := h.DB.Preload("Choices", preloadChoices).First(&g, "id = ?", id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "option group not found"})
+		return nil, false
+	}
+	return &g, true
+}
+
+// PUT /v1/admin/options/:id (admin)
+func (h *OptionsHandler) UpdateGroup(c *gin.Context) {
+	g, ok := h.findGroup(c)
+	if !ok {
+		return
+	}
+	var req optionGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+	if msg := h.apply(&req, g); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+	if err := h.DB.Omit("Choices").Save(g).Error; err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "could not update option group, is the name taken?"})
+		return
+	}
+	c.JSON(http.StatusOK, g.Response())
+}
+
+// DELETE /v1/admin/options/:id (admin). Orders keep their copied option names.
+func (h *OptionsHandler) DeleteGroup(c *gin.Context) {
+	g, ok := h.findGroup(c)
+	if !ok {
+		return
+	}
+	if err := h.DB.Delete(g).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete option group"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+type optionChoiceRequest struct {
+	Name         *string `json:"name"`
+	PriceDelta   *int    `json:"priceDelta"`
+	Default      *bool   `json:"default"`
+	Status       *string `json:"status"`
+	DisplayOrder *int    `json:"displayOrder"`
+}
+
+func (req *optionChoiceRequest) apply(ch *models.OptionChoice) string {
+	if req.Name != nil {
+		if strings.TrimSpace(*req.Name) == "" {
+			return "name cannot be empty"
+		}
+		ch.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.PriceDelta != nil {
+		ch.PriceDelta = *req.PriceDelta
+	}
+	if req.Default != nil {
+		ch.IsDefault = *req.Default
+	}
+	if req.Status != nil {
+		if *req.Status != "available" && *req.Status != "out_of_stock" {
+			return "status must be 'available' or 'out_of_stock'"
+		}
+		ch.Status = *req.Status
+	}
+	if req.DisplayOrder != nil {
+		ch.DisplayOrder = *req.DisplayOrder
+	}
+	return ""
+}
+
+// saveChoice stores ch, clearing the group's other defaults when ch becomes
+// the default so a required group has one fallback.
+func (h *OptionsHandler) saveChoice(ch *models.OptionChoice) error {
+	return h.DB.Transaction(func(tx *gorm.DB) error {
+		if ch.IsDefault {
+			if err := tx.Model(&models.OptionChoice{}).
+				Where("group_id = ? AND id <> ?", ch.GroupID, ch.ID).
+				Update("is_default", false).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Save(ch).Error
+	})
+}
+
+// POST /v1/admin/options/:id/choices (admin) {name, priceDelta, default?, status?, displayOrder?}
+func (h *OptionsHandler) AddChoice(c *gin.Context) {
+	g, ok := h.findGroup(c)
+	if !ok {
+		return
+	}
+	var req optionChoiceRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Name == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+	ch := models.OptionChoice{ID: uuid.New(), GroupID: g.ID, Status: "available"}
+	if msg := req.apply(&ch); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+	if err := h.saveChoice(&ch); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create choice"})
+		return
+	}
+	c.JSON(http.StatusCreated, ch)
+}
+
+func (h *OptionsHandler) findChoice(c *gin.Context) (*models.OptionChoice, bool) {
+	var ch models.OptionChoice
+	if err := h.DB.First(&ch, "id = ? AND group_id = ?", c.Param("choiceId"), c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "choice not found"})
+		return nil, false
+	}
+	return &ch, true
+}
+
+// PUT /v1/admin/options/:id/choices/:choiceId (admin)
+func (h *OptionsHandler) UpdateChoice(c *gin.Context) {
+	ch, ok := h.findChoice(c)
+	if !ok {
+		return
+	}
+	var req optionChoiceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+	if msg := req.apply(ch); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+	if err := h.saveChoice(ch); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update choice"})
+		return
+	}
+	c.JSON(http.StatusOK, ch)
+}
+
+// DELETE /v1/admin/options/:id/choices/:choiceId (admin)
+func (h *OptionsHandler) DeleteChoice(c *gin.Context) {
+	ch, ok := h.findChoice(c)
+	if !ok {
+		return
+	}
+	if err := h.DB.Delete(ch).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete choice"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// resolveOptions checks the chosen add-ons against the groups offered for the
+// frame and size and returns them as order lines. Required groups left out
+// fall back to their default choice.
+func resolveOptions(db *gorm.DB, frameID, sizeID uuid.UUID, choiceIDs []uuid.UUID) ([]models.OrderOption, error) {
+	var groups []models.OptionGroup
+	if err := db.Preload("Choices", preloadChoices).Order("display_order ASC, name ASC").Find(&groups).Error; err != nil {
+		return nil, err
+	}
+
+	wanted := map[uuid.UUID]bool{}
+	for _, id := range choiceIDs {
+		wanted[id] = true
+	}
+
+	lines := []models.OrderOption{}
+	for _, g := range groups {
+		var picked []models.OptionChoice
+		var fallback *models.OptionChoice
+		for i, ch := range g.Choices {
+			if ch.IsDefault && ch.Status == "available" {
+				fallback = &g.Choices[i]
+			}
+			if !wanted[ch.ID] {
+				continue
+			}
+			delete(wanted, ch.ID)
+			if !g.AppliesTo(frameID, sizeID) {
+				return nil, fmt.Errorf("%s is not available for this frame and size", g.Name)
+			}
+			if ch.Status != "available" {
+				return nil, fmt.Errorf("%s: %s is out of stock", g.Name, ch.Name)
+			}
+			picked = append(picked, ch)
+		}
+		if !g.AppliesTo(frameID, sizeID) {
+			continue
+		}
+		if len(picked) > 1 && !g.MultiSelect {
+			return nil, fmt.Errorf("choose only one %s", g.Name)
+		}
+		if len(picked) == 0 && g.Required {
+			if fallback == nil {
+				return nil, fmt.Errorf("choose a %s", g.Name)
+			}
+			picked = append(picked, *fallback)
+		}
+		for _, ch := range picked {
+			lines = append(lines, models.OrderOption{
+				ID:         uuid.New(),
+				GroupID:    g.ID,
+				ChoiceID:   ch.ID,
+				GroupName:  g.Name,
+				ChoiceName: ch.Name,
+				PriceDelta: ch.PriceDelta,
+			})
+		}
+	}
+	if len(wanted) > 0 {
+		return nil, errors.New("unknown option choice")
+	}
+	return lines, nil
+}
+
+// optionsTotal sums the price deltas of an order's add-ons.
+func optionsTotal(lines []models.OrderOption) int {
+	total := 0
+	for _, l := range lines {
+		total += l.PriceDelta
+	}
+	return total
+}
+
+// optionsSummary lists add-ons for the confirmation email, e.g.
+// "Glass: Anti-glare (+₦5000), Mat: White".
+func optionsSummary(lines []models.OrderOption) string {
+	parts := make([]string, len(lines))
+	for i, l := range lines {
+		parts[i] = l.GroupName + ": " + l.ChoiceName
+		if l.PriceDelta > 0 {
+			parts[i] += fmt.Sprintf(" (+₦%d)", l.PriceDelta)
+		} else if l.PriceDelta < 0 {
+			parts[i] += fmt.Sprintf(" (-₦%d)", -l.PriceDelta)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
@@ -1,10 +1,15 @@
 package handlers
 
 import (
+	"bytes"
+	"context"
 	"crypto/rand"
+	"errors"
 	"fmt"
+	"image"
 	"log"
 	"net/http"
+	"path"
 	"time"
 
 	// "net/http"
@@ -15,12 +20,23 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/olamideolayemi/framelane-api/internal/email"
+	"github.com/olamideolayemi/framelane-api/internal/imaging"
+	"github.com/olamideolayemi/framelane-api/internal/inventory"
 	"github.com/olamideolayemi/framelane-api/internal/models"
+	"github.com/olamideolayemi/framelane-api/internal/storage"
+)
+
+const (
+	downloadURLTTL = 5 * time.Minute
+	emailURLTTL    = 7 * 24 * time.Hour
 )
 
 type OrdersHandler struct {
-	DB    *gorm.DB
-	Email *email.Sender
+	DB        *gorm.DB
+	Store     storage.Store
+	Email     *email.Sender
+	Inventory *inventory.Service
+	MaxPixels int // largest original decoded for print files; 0 means no limit
 }
 
 func randID() string {
@@ -50,11 +66,15 @@ func (h *OrdersHandler) Create(c *gin.Context) {
 	}
 
 	var in struct {
-		Address  string `json:"address" binding:"required"`
-		FrameID  string `json:"frameId" binding:"required"`
-		SizeID   string `json:"sizeId" binding:"required"`
-		Notes    string `json:"notes" binding:"omitempty"`
-		ImageURL string `json:"imageUrl" binding:"required"`
+		Address string `json:"address" binding:"required"`
+		FrameID string `json:"frameId" binding:"required"`
+		SizeID  string `json:"sizeId"`  // a catalogue size, or
+		QuoteID string `json:"quoteId"` // a custom size quote
+		Notes   string `json:"notes" binding:"omitempty"`
+		AssetID string `json:"assetId" binding:"required"`
+
+		Placement *models.Placement `json:"placement"` // crop, rotation and fit; defaults to a centred fill
+		Options   []uuid.UUID       `json:"options"`   // chosen add-on choice IDs
 	}
 
 	// Bind JSON
@@ -63,45 +83,120 @@ func (h *OrdersHandler) Create(c *gin.Context) {
 		return
 	}
 
-	// Parse Frame ID
-	frameID, err := uuid.Parse(in.FrameID)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid frame ID"})
+	var quote *models.CustomSizeQuote
+	if in.QuoteID != "" {
+		var ok bool
+		if quote, ok = h.loadOpenQuote(c, uid, in.QuoteID); !ok {
+			return
+		}
+		if quote.FrameID.String() != in.FrameID {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Quote is for a different frame"})
+			return
+		}
+		sizeID, err := quoteSize(h.DB, quote)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not prepare custom size"})
+			return
+		}
+		in.SizeID = sizeID.String()
+	} else if in.SizeID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "sizeId or quoteId is required"})
+		return
+	} else if !h.catalogueSize(c, in.SizeID) {
 		return
 	}
 
-	var frame models.Frame
-	if err := h.DB.First(&frame, "id = ?", frameID).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Frame not found"})
+	frame, size, ok := h.loadFrameAndSize(c, in.FrameID, in.SizeID)
+	if !ok {
 		return
 	}
 
-	// Parse FrameSize ID
-	sizeID, err := uuid.Parse(in.SizeID)
+	// Status is the admin's switch; stock is checked per frame and size below
+	if frame.Status != "available" || size.Status != "available" {
+		c.JSON(http.StatusConflict, gin.H{"error": "This frame or size is unavailable"})
+		return
+	}
+	if n, tracked, err := h.Inventory.Available(frame.ID, size.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not check stock"})
+		return
+	} else if tracked && n < 1 {
+		c.JSON(http.StatusConflict, gin.H{"error": "This frame is out of stock in that size"})
+		return
+	}
+
+	options, err := resolveOptions(h.DB, frame.ID, size.ID, in.Options)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid frame size ID"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	var size models.FrameSize
-	if err := h.DB.First(&size, "id = ?", sizeID).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Frame size not found"})
+	// The image must be a finalized upload owned by the caller
+	asset, ok := h.loadReadyAsset(c, uid, in.AssetID)
+	if !ok {
+		return
+	}
+
+	// Work out how the image sits on the print so the workshop doesn't have to guess
+	var placement models.Placement
+	if printW, printH, ok := size.Dimensions(); ok {
+		placement, err = resolvePlacement(in.Placement, asset, printW, printH)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	} else if in.Placement != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Frame size has no dimensions to place the image on"})
+		return
+	}
+
+	// Refuse prints whose crop would come out badly; warnings go back with the order
+	quality, checked, err := checkPrintQuality(h.DB, asset, size, placement)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not check print quality"})
+		return
+	}
+	if checked && quality.Verdict == imaging.PrintReject {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": quality.Message, "printQuality": quality})
 		return
 	}
 
 	// Create order
 	order := models.Order{
-		OrderID:  strings.ToUpper("FL-" + randID()),
-		UserID:   uid,
-		FrameID:  frame.ID,
-		Frame:    frame,
-		SizeID:   size.ID,
-		Size:     size,
-		ImageURL: in.ImageURL,
-		Notes:    in.Notes,
+		OrderID:   strings.ToUpper("FL-" + randID()),
+		UserID:    uid,
+		FrameID:   frame.ID,
+		Frame:     *frame,
+		SizeID:    size.ID,
+		Size:      *size,
+		AssetID:   &asset.ID,
+		Placement: placement,
+		Options:   options,
+		Total:     size.Price + optionsTotal(options),
+		Notes:     in.Notes,
+	}
+	if checked {
+		order.PrintDPI = quality.DPI
+		order.PrintQuality = quality.Verdict
+	}
+
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&order).Error; err != nil {
+			return err
+		}
+		if quote == nil {
+			return nil
+		}
+		res := tx.Model(&models.CustomSizeQuote{}).Where("id = ? AND order_id IS NULL", quote.ID).Update("order_id", order.ID)
+		if res.Error == nil && res.RowsAffected == 0 {
+			return errQuoteUsed
+		}
+		return res.Error
+	})
+	if errors.Is(err, errQuoteUsed) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Quote has already been ordered"})
+		return
 	}
-
-	if err := h.DB.Create(&order).Error; err != nil {
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not create order", "details": err.Error()})
 		return
 	}
@@ -114,11 +209,12 @@ func (h *OrdersHandler) Create(c *gin.Context) {
 			"Frame":        frame.Name,
 			"Size":         size.Name,
 			"Price":        fmt.Sprintf("₦%d", size.Price),
-			"ImageURL":     order.ImageURL,
+			"ImageURL":     h.emailImageURL(c, asset),
 			"Address":      in.Address,
 			"Notes":        in.Notes,
-			"Total":        "₦0",
-			"Status":       "Pending",
+			"Options":      optionsSummary(options),
+			"Total":        fmt.Sprintf("₦%d", order.Total),
+			"Status":       models.OrderPending,
 			"Year":         fmt.Sprintf("%d", time.Now().Year()),
 		}
 		if err := SendOrderConfirmation(h.Email, user.Email, data); err != nil {
@@ -126,18 +222,172 @@ func (h *OrdersHandler) Create(c *gin.Context) {
 		}
 	}
 
-	c.JSON(http.StatusCreated, gin.H{
+	resp := gin.H{
 		"message":   "Order placed successfully",
 		"orderId":   order.OrderID,
 		"id":        order.ID,
 		"frame":     frame.Name,
 		"size":      size.Name,
 		"price":     size.Price,
-		"imageUrl":  order.ImageURL,
+		"options":   order.Options,
+		"total":     order.Total,
+		"assetId":   order.AssetID,
+		"imageUrl":  h.imageURL(c, &order),
+		"placement": order.Placement,
 		"notes":     order.Notes,
 		"createdAt": order.CreatedAt,
 		"updatedAt": order.UpdatedAt,
-	})
+	}
+	if checked {
+		resp["printQuality"] = quality
+	}
+	c.JSON(http.StatusCreated, resp)
+}
+
+// POST /v1/orders/quote -> price and print quality for a frame, size and image, without ordering
+func (h *OrdersHandler) Quote(c *gin.Context) {
+	uid, err := uuid.Parse(c.GetString("uid"))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var in struct {
+		FrameID   string            `json:"frameId" binding:"required"`
+		SizeID    string            `json:"sizeId" binding:"required"`
+		AssetID   string            `json:"assetId"`
+		Placement *models.Placement `json:"placement"` // as for orders; checked with the asset
+		Options   []uuid.UUID       `json:"options"`
+	}
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data", "details": err.Error()})
+		return
+	}
+
+	frame, size, ok := h.loadFrameAndSize(c, in.FrameID, in.SizeID)
+	if !ok {
+		return
+	}
+
+	options, err := resolveOptions(h.DB, frame.ID, size.ID, in.Options)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp := gin.H{
+		"frame":   gin.H{"id": frame.ID, "name": frame.Name},
+		"size":    gin.H{"id": size.ID, "name": size.Name, "price": size.Price},
+		"price":   size.Price,
+		"options": options,
+		"total":   size.Price + optionsTotal(options),
+		"inStock": frame.Status == "available" && size.Status == "available",
+	}
+	if n, tracked, err := h.Inventory.Available(frame.ID, size.ID); err == nil && tracked && n < 1 {
+		resp["inStock"] = false
+	}
+
+	if in.AssetID != "" {
+		asset, ok := h.loadReadyAsset(c, uid, in.AssetID)
+		if !ok {
+			return
+		}
+		var placement models.Placement
+		if printW, printH, ok := size.Dimensions(); ok {
+			placement, err = resolvePlacement(in.Placement, asset, printW, printH)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
+		quality, checked, err := checkPrintQuality(h.DB, asset, size, placement)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not check print quality"})
+			return
+		}
+		if checked {
+			resp["printQuality"] = quality
+		}
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+func (h *OrdersHandler) loadFrameAndSize(c *gin.Context, frameIDStr, sizeIDStr string) (*models.Frame, *models.FrameSize, bool) {
+	// Parse Frame ID
+	frameID, err := uuid.Parse(frameIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid frame ID"})
+		return nil, nil, false
+	}
+
+	var frame models.Frame
+	if err := h.DB.First(&frame, "id = ?", frameID).Error; err != nil {
+		if h.archived(&models.Frame{}, frameID) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Frame is archived and can no longer be ordered"})
+		} else {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Frame not found"})
+		}
+		return nil, nil, false
+	}
+
+	// Parse FrameSize ID
+	sizeID, err := uuid.Parse(sizeIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid frame size ID"})
+		return nil, nil, false
+	}
+
+	var size models.FrameSize
+	if err := h.DB.First(&size, "id = ?", sizeID).Error; err != nil {
+		if h.archived(&models.FrameSize{}, sizeID) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Frame size is archived and can no longer be ordered"})
+		} else {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Frame size not found"})
+		}
+		return nil, nil, false
+	}
+	return &frame, &size, true
+}
+
+// archived reports whether the catalogue row with id exists but is archived.
+func (h *OrdersHandler) archived(model any, id uuid.UUID) bool {
+	var n int64
+	h.DB.Unscoped().Model(model).Where("id = ? AND deleted_at IS NOT NULL", id).Count(&n)
+	return n > 0
+}
+
+var errQuoteUsed = errors.New("quote already ordered")
+
+// catalogueSize refuses the hidden sizes made for custom quotes, which can
+// only be ordered through their quote.
+func (h *OrdersHandler) catalogueSize(c *gin.Context, sizeIDStr string) bool {
+	var n int64
+	h.DB.Model(&models.FrameSize{}).Where("id = ? AND custom = ?", sizeIDStr, true).Count(&n)
+	if n > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Custom sizes must be ordered with their quoteId"})
+		return false
+	}
+	return true
+}
+
+func (h *OrdersHandler) loadReadyAsset(c *gin.Context, uid uuid.UUID, assetIDStr string) (*models.Asset, bool) {
+	assetID, err := uuid.Parse(assetIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid asset ID"})
+		return nil, false
+	}
+
+	var asset models.Asset
+	if err := h.DB.First(&asset, "id = ? AND user_id = ?", assetID, uid).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Image not found"})
+		return nil, false
+	}
+	if asset.Status != models.AssetReady {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Image upload has not been finalized"})
+		return nil, false
+	}
+	return &asset, true
 }
 
 // GET /v1/orders (auth) -> list own
@@ -184,8 +434,10 @@ func (h *OrdersHandler) ListMine(c *gin.Context) {
 		Preload("User", func(db *gorm.DB) *gorm.DB {
 			return db.Select("id", "name")
 		}).
-		Preload("Frame").
-		Preload("Size").
+		Preload("Frame", models.WithArchived).
+		Preload("Size", models.WithArchived).
+		Preload("Asset").
+		Preload("Options").
 		Order("created_at DESC").
 		Limit(limit).
 		Offset(offset).
@@ -221,13 +473,18 @@ func (h *OrdersHandler) ListMine(c *gin.Context) {
 			}{
 				ID:    o.Size.ID,
 				Name:  o.Size.Name,
-				Price: o.Size.Price,
+				Price: o.SizePrice(), // at order time, not today's price
 			},
-			ImageURL:  o.ImageURL,
-			Status:    o.Status,
-			Notes:     o.Notes,
-			CreatedAt: o.CreatedAt,
-			UpdatedAt: o.UpdatedAt,
+			AssetID:    o.AssetID,
+			ImageURL:   h.imageURL(c, &o),
+			Thumbnails: h.thumbnails(c, o.Asset),
+			Placement:  o.Placement,
+			Options:    o.Options,
+			Total:      o.Amount(),
+			Status:     o.Status,
+			Notes:      o.Notes,
+			CreatedAt:  o.CreatedAt,
+			UpdatedAt:  o.UpdatedAt,
 		}
 
 	}
@@ -271,8 +528,10 @@ func (h *OrdersHandler) ListAll(c *gin.Context) {
 		Preload("User", func(db *gorm.DB) *gorm.DB {
 			return db.Select("id", "name")
 		}).
-		Preload("Frame").
-		Preload("Size").
+		Preload("Frame", models.WithArchived).
+		Preload("Size", models.WithArchived).
+		Preload("Asset").
+		Preload("Options").
 		Order("created_at DESC").
 		Limit(limit).
 		Offset(offset).
@@ -308,13 +567,18 @@ func (h *OrdersHandler) ListAll(c *gin.Context) {
 			}{
 				ID:    o.Size.ID,
 				Name:  o.Size.Name,
-				Price: o.Size.Price,
+				Price: o.SizePrice(), // at order time, not today's price
 			},
-			ImageURL:  o.ImageURL,
-			Status:    o.Status,
-			Notes:     o.Notes,
-			CreatedAt: o.CreatedAt,
-			UpdatedAt: o.UpdatedAt,
+			AssetID:    o.AssetID,
+			ImageURL:   h.imageURL(c, &o),
+			Thumbnails: h.thumbnails(c, o.Asset),
+			Placement:  o.Placement,
+			Options:    o.Options,
+			Total:      o.Amount(),
+			Status:     o.Status,
+			Notes:      o.Notes,
+			CreatedAt:  o.CreatedAt,
+			UpdatedAt:  o.UpdatedAt,
 		}
 	}
 
@@ -357,7 +621,23 @@ func (h *OrdersHandler) UpdateStatus(c *gin.Context) {
 		}
 	}
 
-	if err := h.DB.Model(&order).Update("status", in.Status).Error; err != nil {
+	// Retention runs from delivery, not from the order's last edit
+	status := models.NormalizeOrderStatus(in.Status)
+	update := map[string]any{"status": status}
+	if status == models.OrderDelivered {
+		if order.DeliveredAt == nil {
+			update["delivered_at"] = time.Now()
+		}
+	} else if order.DeliveredAt != nil {
+		update["delivered_at"] = nil
+	}
+	err := h.Inventory.Transition(&order, status, func(tx *gorm.DB) error {
+		return tx.Model(&order).Updates(update).Error
+	})
+	if errors.Is(err, inventory.ErrOutOfStock) {
+		c.JSON(http.StatusConflict, gin.H{"error": "not enough stock for this order"})
+		return
+	} else if err != nil {
 		c.JSON(500, gin.H{"error": err.Error()})
 		return
 	}
@@ -369,7 +649,7 @@ func (h *OrdersHandler) UpdateStatus(c *gin.Context) {
 			data := map[string]string{
 				"CustomerName": user.Name,
 				"OrderID":      order.OrderID,
-				"NewStatus":    in.Status,
+				"NewStatus":    status,
 				"OrderLink":    fmt.Sprintf("https://framelane.com/track/%s", order.OrderID),
 				"Year":         fmt.Sprintf("%d", time.Now().Year()),
 			}
@@ -378,7 +658,7 @@ func (h *OrdersHandler) UpdateStatus(c *gin.Context) {
 		}
 	}
 
-	c.JSON(200, gin.H{"ok": true, "status": in.Status})
+	c.JSON(200, gin.H{"ok": true, "status": status})
 }
 
 // DELETE /v1/admin/orders/:id (admin)
@@ -453,3 +733,146 @@ func SendOrderShippedNotification(sender *email.Sender, customerEmail string, da
 
 // // In your order update route
 // hub.broadcast <- []byte(`{"event":"order_updated","orderId":"123","status":"shipped"}`)
+
+// thumbnails returns short-lived URLs for an order's resized images, once generated.
+func (h *OrdersHandler) thumbnails(ctx context.Context, a *models.Asset) *models.Thumbnails {
+	if a == nil || a.ThumbStatus != models.ThumbsDone {
+		return nil
+	}
+	return &models.Thumbnails{
+		Thumb:   h.Store.ViewURL(ctx, a.ThumbKey),
+		Medium:  h.Store.ViewURL(ctx, a.MediumKey),
+		Preview: h.Store.ViewURL(ctx, a.PreviewKey),
+	}
+}
+
+// imageURL mints a view URL for the order's original image. Orders from before
+// uploads were tracked only have the plain object URL they were stored with.
+func (h *OrdersHandler) imageURL(ctx context.Context, o *models.Order) string {
+	if o.Asset != nil {
+		return h.Store.ViewURL(ctx, o.Asset.ObjectKey)
+	}
+	if key, ok := h.Store.KeyFromURL(o.ImageURL); ok {
+		return h.Store.ViewURL(ctx, key)
+	}
+	return ""
+}
+
+// emailImageURL links the preview from the confirmation email. Emails are read
+// long after sending, so it gets the longest lifetime S3 allows.
+func (h *OrdersHandler) emailImageURL(ctx context.Context, a *models.Asset) string {
+	key := a.PreviewKey
+	if key == "" {
+		key = a.ObjectKey
+	}
+	u, err := h.Store.PresignGet(ctx, key, "", emailURLTTL)
+	if err != nil {
+		log.Printf("presign email image: %v", err)
+		return ""
+	}
+	return u
+}
+
+// GET /v1/admin/orders/:id/original (admin) -> redirect to a one-off download of the uploaded file
+func (h *OrdersHandler) DownloadOriginal(c *gin.Context) {
+	var order models.Order
+	if err := h.DB.Preload("Asset").First(&order, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+		return
+	}
+	key := ""
+	if order.Asset != nil {
+		key = order.Asset.ObjectKey
+	} else if k, ok := h.Store.KeyFromURL(order.ImageURL); ok {
+		key = k
+	}
+	if key == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Order has no stored image"})
+		return
+	}
+
+	u, err := h.Store.PresignGet(c, key, order.OrderID+path.Ext(key), downloadURLTTL)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not create download link"})
+		return
+	}
+	log.Printf("admin %s downloaded original for order %s", c.GetString("uid"), order.OrderID)
+	c.Redirect(http.StatusFound, u)
+}
+
+// POST /v1/admin/orders/:id/print-file (admin) -> render the print-ready crop at full resolution
+func (h *OrdersHandler) RenderPrintFile(c *gin.Context) {
+	var order models.Order
+	if err := h.DB.Preload("Asset").Preload("Size", models.WithArchived).First(&order, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+		return
+	}
+	if order.Asset == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Order has no tracked image"})
+		return
+	}
+	printW, printH, ok := order.Size.Dimensions()
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Frame size has no dimensions"})
+		return
+	}
+
+	// Orders placed before placements were recorded get the default centred fill
+	p := order.Placement
+	if p.CropW == 0 || p.CropH == 0 {
+		var err error
+		if p, err = resolvePlacement(nil, order.Asset, printW, printH); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	if err := imaging.CheckPixels(order.Asset.Width, order.Asset.Height, h.MaxPixels); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Image is too large to render"})
+		return
+	}
+	obj, err := h.Store.Get(c, order.Asset.ObjectKey)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not read image"})
+		return
+	}
+	img, err := imaging.Decode(obj, h.MaxPixels)
+	obj.Close()
+	if errors.Is(err, imaging.ErrTooManyPixels) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Image is too large to render"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not decode image"})
+		return
+	}
+	img = imaging.Rotate(img, p.Rotation)
+
+	crop := image.Rect(p.CropX, p.CropY, p.CropX+p.CropW, p.CropY+p.CropH)
+	if (p.CropW > p.CropH) != (printW > printH) {
+		printW, printH = printH, printW
+	}
+	out, err := imaging.EncodeJPEG(imaging.RenderPrint(img, crop, printW, printH, p.FitMode == models.FitFit), 95)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not render print file"})
+		return
+	}
+
+	key := fmt.Sprintf("renders/%s/print.jpg", order.ID)
+	if err := h.Store.Put(c, key, bytes.NewReader(out), int64(len(out)), "image/jpeg"); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not store print file"})
+		return
+	}
+	if err := h.DB.Model(&order).Update("print_file_key", key).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not save print file"})
+		return
+	}
+
+	b := img.Bounds()
+	c.JSON(http.StatusOK, gin.H{
+		"key":       key,
+		"url":       h.Store.ViewURL(c, key),
+		"placement": p,
+		"source":    gin.H{"width": b.Dx(), "height": b.Dy()},
+	})
+}
@@ -4,11 +4,12 @@ import (
 	"os"
 
 	"github.com/gin-gonic/gin"
+	"github.com/olamideolayemi/framelane-api/internal/inventory"
 	"github.com/olamideolayemi/framelane-api/internal/payments"
 	"gorm.io/gorm"
 )
 
-type PaymentsHandler struct{ Stripe *payments.Stripe; DB *gorm.DB }
+type PaymentsHandler struct{ Stripe *payments.Stripe; DB *gorm.DB; Inventory *inventory.Service }
 
 type intentDTO struct {
   OrderID string `json:"order_id" binding:"required"`
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"errors"
+	"image"
+	"math"
+
+	"github.com/olamideolayemi/framelane-api/internal/imaging"
+	"github.com/olamideolayemi/framelane-api/internal/models"
+)
+
+// aspectTolerance is how far a fill crop may stray from the print's aspect
+// ratio; a 1% difference disappears under the frame rebate.
+const aspectTolerance = 0.01
+
+// resolvePlacement validates the requested placement against the image and
+// print size. Without one, the image is centre-cropped to fill the print.
+func resolvePlacement(in *models.Placement, asset *models.Asset, printW, printH float64) (models.Placement, error) {
+	p := models.Placement{FitMode: models.FitFill}
+	if in != nil {
+		p = *in
+		if p.FitMode == "" {
+			p.FitMode = models.FitFill
+		}
+	}
+	if p.FitMode != models.FitFill && p.FitMode != models.FitFit {
+		return p, errors.New("fitMode must be fill or fit")
+	}
+	if p.Rotation != 0 && p.Rotation != 90 && p.Rotation != 180 && p.Rotation != 270 {
+		return p, errors.New("rotation must be 0, 90, 180 or 270")
+	}
+
+	w, h := asset.UprightSize()
+	if p.Rotation == 90 || p.Rotation == 270 {
+		w, h = h, w
+	}
+	if w <= 0 || h <= 0 {
+		return p, errors.New("image dimensions are unknown")
+	}
+
+	// Hang the print the same way round as the (rotated) image
+	if (w > h) != (printW > printH) {
+		printW, printH = printH, printW
+	}
+
+	if p.CropW == 0 && p.CropH == 0 {
+		r := image.Rect(0, 0, w, h)
+		if p.FitMode == models.FitFill {
+			r = imaging.CoverCrop(r, int(printW*100), int(printH*100))
+		}
+		p.CropX, p.CropY, p.CropW, p.CropH = r.Min.X, r.Min.Y, r.Dx(), r.Dy()
+		return p, nil
+	}
+
+	if p.CropX < 0 || p.CropY < 0 || p.CropW <= 0 || p.CropH <= 0 || p.CropX+p.CropW > w || p.CropY+p.CropH > h {
+		return p, errors.New("crop must lie within the image")
+	}
+	if p.FitMode == models.FitFill {
+		crop := float64(p.CropW) / float64(p.CropH)
+		if !aspectClose(crop, printW/printH) && !aspectClose(crop, printH/printW) {
+			return p, errors.New("crop does not match the frame size's aspect ratio; use fit mode for a border")
+		}
+	}
+	return p, nil
+}
+
+func aspectClose(a, b float64) bool {
+	return math.Abs(a-b)/b <= aspectTolerance
+}
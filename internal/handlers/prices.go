@@ -0,0 +1,235 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/olamideolayemi/framelane-api/internal/models"
+	"github.com/olamideolayemi/framelane-api/internal/pricing"
+)
+
+// parseWhen reads a date query or body value as RFC 3339 or YYYY-MM-DD.
+func parseWhen(v string) (time.Time, bool) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, true
+	}
+	if t, err := time.Parse(time.DateOnly, v); err == nil {
+		return t, true
+	}
+	return time.Time{}, false
+}
+
+// adminID is the calling admin, recorded on price versions.
+func adminID(c *gin.Context) *uuid.UUID {
+	id, err := uuid.Parse(c.GetString("uid"))
+	if err != nil {
+		return nil
+	}
+	return &id
+}
+
+// findSize loads a size by ID, archived or not: its price history outlives it.
+func (h *FrameHandler) findSize(c *gin.Context) (*models.FrameSize, bool) {
+	var size models.FrameSize
+	if err := models.WithArchived(h.DB).First(&size, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "frame size not found"})
+		return nil, false
+	}
+	return &size, true
+}
+
+// GET /v1/admin/frames/size/:id/prices?at= (admin) -> price history, scheduled changes and the price at a date
+func (h *FrameHandler) ListPrices(c *gin.Context) {
+	at, ok := priceAtQuery(c)
+	if !ok {
+		return
+	}
+	size, ok := h.findSize(c)
+	if !ok {
+		return
+	}
+	resp, err := h.sizePrices(size, at)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch prices"})
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// GET /v1/admin/frames/:id/prices?at= (admin) -> the frame's price history in each size.
+// A frame costs its size's price, so this is every catalogue size's history.
+func (h *FrameHandler) ListFramePrices(c *gin.Context) {
+	at, ok := priceAtQuery(c)
+	if !ok {
+		return
+	}
+	var frame models.Frame
+	if err := models.WithArchived(h.DB).Preload("Images", preloadImages).First(&frame, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "frame type not found"})
+		return
+	}
+	var sizes []models.FrameSize
+	if err := models.WithArchived(h.DB).Where("custom = ?", false).Order("display_order ASC, price ASC").Find(&sizes).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch prices"})
+		return
+	}
+	prices := make([]gin.H, 0, len(sizes))
+	for i := range sizes {
+		resp, err := h.sizePrices(&sizes[i], at)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch prices"})
+			return
+		}
+		prices = append(prices, resp)
+	}
+	c.JSON(http.StatusOK, gin.H{"frame": h.frameResponse(c, &frame), "sizes": prices})
+}
+
+// priceAtQuery reads the optional "at" query, answering 400 if it's malformed.
+func priceAtQuery(c *gin.Context) (*time.Time, bool) {
+	v := c.Query("at")
+	if v == "" {
+		return nil, true
+	}
+	at, ok := parseWhen(v)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "at must be a date (YYYY-MM-DD) or RFC 3339 time"})
+		return nil, false
+	}
+	return &at, true
+}
+
+// sizePrices is a size's price history and scheduled changes, plus its price
+// at at when given.
+func (h *FrameHandler) sizePrices(size *models.FrameSize, at *time.Time) (gin.H, error) {
+	var versions []models.PriceVersion
+	if err := h.DB.Where("size_id = ?", size.ID).Order("effective_from DESC, created_at DESC").Find(&versions).Error; err != nil {
+		return nil, err
+	}
+	history, scheduled := []models.PriceVersion{}, []models.PriceVersion{}
+	for _, v := range versions {
+		if v.ActivatedAt == nil {
+			scheduled = append(scheduled, v)
+		} else {
+			history = append(history, v)
+		}
+	}
+	resp := gin.H{"size": size.Response(), "current": size.Price, "history": history, "scheduled": scheduled}
+
+	if at != nil {
+		price, known, err := pricing.At(h.DB, size.ID, *at)
+		if err != nil {
+			return nil, err
+		}
+		if known {
+			resp["priceAt"] = gin.H{"at": *at, "price": price}
+		} else {
+			resp["priceAt"] = gin.H{"at": *at, "price": nil}
+		}
+	}
+	return resp, nil
+}
+
+// POST /v1/admin/frames/size/:id/prices (admin) {price, effectiveFrom, note?} -> schedule a price change
+func (h *FrameHandler) SchedulePrice(c *gin.Context) {
+	size, ok := h.findSize(c)
+	if !ok {
+		return
+	}
+	var in struct {
+		Price         int    `json:"price" binding:"required"`
+		EffectiveFrom string `json:"effectiveFrom" binding:"required"`
+		Note          string `json:"note"`
+	}
+	if err := c.ShouldBindJSON(&in); err != nil || in.Price <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "price (positive) and effectiveFrom are required"})
+		return
+	}
+	from, ok := parseWhen(in.EffectiveFrom)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "effectiveFrom must be a date (YYYY-MM-DD) or RFC 3339 time"})
+		return
+	}
+	if !from.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "effectiveFrom must be in the future; edit the size to change its price now"})
+		return
+	}
+
+	v := models.PriceVersion{
+		ID:            uuid.New(),
+		SizeID:        size.ID,
+		Price:         in.Price,
+		EffectiveFrom: from,
+		Note:          in.Note,
+		CreatedBy:     adminID(c),
+	}
+	if err := h.DB.Create(&v).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to schedule price"})
+		return
+	}
+	c.JSON(http.StatusCreated, v)
+}
+
+// DELETE /v1/admin/frames/size/:id/prices/:versionId (admin) -> cancel a scheduled change
+func (h *FrameHandler) CancelPrice(c *gin.Context) {
+	res := h.DB.Where("id = ? AND size_id = ? AND activated_at IS NULL", c.Param("versionId"), c.Param("id")).
+		Delete(&models.PriceVersion{})
+	if res.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to cancel price"})
+		return
+	}
+	if res.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "scheduled price not found; prices already in effect stay in the history"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// GET /v1/admin/prices/preview?at= (admin) -> every catalogue size's price now and at a future date
+func (h *FrameHandler) PreviewPrices(c *gin.Context) {
+	at, ok := parseWhen(c.Query("at"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "at must be a date (YYYY-MM-DD) or RFC 3339 time"})
+		return
+	}
+	var sizes []models.FrameSize
+	if err := h.DB.Where("custom = ?", false).Order("display_order ASC, price ASC").Find(&sizes).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch sizes"})
+		return
+	}
+
+	type preview struct {
+		SizeID  uuid.UUID `json:"sizeId"`
+		Name    string    `json:"name"`
+		Current int       `json:"current"`
+		Price   int       `json:"price"`
+		Change  int       `json:"change"`
+	}
+	out := make([]preview, 0, len(sizes))
+	for _, s := range sizes {
+		p := preview{SizeID: s.ID, Name: s.Name, Current: s.Price, Price: s.Price}
+		if price, known, err := pricing.At(h.DB, s.ID, at); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch prices"})
+			return
+		} else if known {
+			p.Price = price
+		}
+		p.Change = p.Price - p.Current
+		out = append(out, p)
+	}
+	c.JSON(http.StatusOK, gin.H{"at": at, "sizes": out})
+}
+
+// recordPrice saves a size together with its immediate price version.
+func (h *FrameHandler) recordPrice(c *gin.Context, save func(tx *gorm.DB) error, size *models.FrameSize, note string) error {
+	return h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := save(tx); err != nil {
+			return err
+		}
+		return pricing.Record(tx, size.ID, size.Price, note, adminID(c))
+	})
+}
@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/olamideolayemi/framelane-api/internal/imaging"
+	"github.com/olamideolayemi/framelane-api/internal/models"
+)
+
+type SettingsHandler struct {
+	DB *gorm.DB
+}
+
+// loadPrintQuality returns the DPI thresholds, creating the defaults row on first use.
+func loadPrintQuality(db *gorm.DB) (models.PrintQualitySettings, error) {
+	s := models.PrintQualitySettings{ID: 1, WarnDPI: 150, MinDPI: 100}
+	err := db.FirstOrCreate(&s, models.PrintQualitySettings{ID: 1}).Error
+	return s, err
+}
+
+// checkPrintQuality compares the part of a ready asset that gets printed, the
+// placement's crop, with a frame size. ok is false when the size name carries
+// no parseable dimensions, in which case no check is done.
+func checkPrintQuality(db *gorm.DB, asset *models.Asset, size *models.FrameSize, p models.Placement) (pc imaging.PrintCheck, ok bool, err error) {
+	w, h, ok := size.Dimensions()
+	if !ok || p.CropW <= 0 || p.CropH <= 0 {
+		return pc, false, nil
+	}
+	s, err := loadPrintQuality(db)
+	if err != nil {
+		return pc, false, err
+	}
+	return imaging.CheckPrint(p.CropW, p.CropH, w, h, s.WarnDPI, s.MinDPI), true, nil
+}
+
+// GET /v1/admin/settings/print-quality (admin)
+func (h *SettingsHandler) GetPrintQuality(c *gin.Context) {
+	s, err := loadPrintQuality(h.DB)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load settings"})
+		return
+	}
+	c.JSON(http.StatusOK, s)
+}
+
+// PUT /v1/admin/settings/print-quality (admin)
+func (h *SettingsHandler) UpdatePrintQuality(c *gin.Context) {
+	var req struct {
+		WarnDPI *int `json:"warnDpi"`
+		MinDPI  *int `json:"minDpi"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	s, err := loadPrintQuality(h.DB)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load settings"})
+		return
+	}
+	if req.WarnDPI != nil {
+		s.WarnDPI = *req.WarnDPI
+	}
+	if req.MinDPI != nil {
+		s.MinDPI = *req.MinDPI
+	}
+	if s.MinDPI <= 0 || s.WarnDPI < s.MinDPI {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "minDpi must be positive and warnDpi at least minDpi"})
+		return
+	}
+
+	if err := h.DB.Save(&s).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update settings"})
+		return
+	}
+	c.JSON(http.StatusOK, s)
+}
+
+// loadCustomSizePricing returns the custom size formula, creating the defaults row on first use.
+func loadCustomSizePricing(db *gorm.DB) (models.CustomSizePricing, error) {
+	p := models.CustomSizePricing{ID: 1, BasePrice: 3000, PerSqInch: 12, PerPerimeterInch: 60, MinPrice: 6000, MinDimensionIn: 4, MaxDimensionIn: 60, QuoteTTLHours: 72}
+	err := db.FirstOrCreate(&p, models.CustomSizePricing{ID: 1}).Error
+	return p, err
+}
+
+// GET /v1/admin/settings/custom-size-pricing (admin)
+func (h *SettingsHandler) GetCustomSizePricing(c *gin.Context) {
+	p, err := loadCustomSizePricing(h.DB)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load settings"})
+		return
+	}
+	c.JSON(http.StatusOK, p)
+}
+
+// PUT /v1/admin/settings/custom-size-pricing (admin)
+func (h *SettingsHandler) UpdateCustomSizePricing(c *gin.Context) {
+	var req struct {
+		BasePrice        *int     `json:"basePrice"`
+		PerSqInch        *float64 `json:"perSqInch"`
+		PerPerimeterInch *float64 `json:"perPerimeterInch"`
+		MinPrice         *int     `json:"minPrice"`
+		MinDimensionIn   *float64 `json:"minDimensionIn"`
+		MaxDimensionIn   *float64 `json:"maxDimensionIn"`
+		QuoteTTLHours    *int     `json:"quoteTtlHours"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	p, err := loadCustomSizePricing(h.DB)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load settings"})
+		return
+	}
+	if req.BasePrice != nil {
+		p.BasePrice = *req.BasePrice
+	}
+	if req.PerSqInch != nil {
+		p.PerSqInch = *req.PerSqInch
+	}
+	if req.PerPerimeterInch != nil {
+		p.PerPerimeterInch = *req.PerPerimeterInch
+	}
+	if req.MinPrice != nil {
+		p.MinPrice = *req.MinPrice
+	}
+	if req.MinDimensionIn != nil {
+		p.MinDimensionIn = *req.MinDimensionIn
+	}
+	if req.MaxDimensionIn != nil {
+		p.MaxDimensionIn = *req.MaxDimensionIn
+	}
+	if req.QuoteTTLHours != nil {
+		p.QuoteTTLHours = *req.QuoteTTLHours
+	}
+	if p.BasePrice < 0 || p.PerSqInch < 0 || p.PerPerimeterInch < 0 || p.MinPrice < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "prices cannot be negative"})
+		return
+	}
+	if p.MinDimensionIn <= 0 || p.MaxDimensionIn < p.MinDimensionIn {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "minDimensionIn must be positive and maxDimensionIn at least minDimensionIn"})
+		return
+	}
+	if p.QuoteTTLHours < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "quoteTtlHours must be at least 1"})
+		return
+	}
+
+	if err := h.DB.Save(&p).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update settings"})
+		return
+	}
+	c.JSON(http.StatusOK, p)
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/olamideolayemi/framelane-api/internal/storage"
+)
+
+// LocalStorageHandler serves the signed URLs handed out by the local storage
+// backend, standing in for S3 when running without MinIO.
+type LocalStorageHandler struct {
+	Store    *storage.Local
+	MaxBytes int64 // hard cap on any request body
+}
+
+// GET /v1/storage/object?key=&expires=&signature= -> download
+func (h *LocalStorageHandler) Download(c *gin.Context) {
+	g, err := h.Store.Verify(storage.LocalOpGet, c.Query)
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	st, err := h.Store.Stat(c, g.Key)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "object not found"})
+		return
+	}
+	r, err := h.Store.Get(c, g.Key)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "object not found"})
+		return
+	}
+	defer r.Close()
+
+	c.Header("Content-Type", st.ContentType)
+	if g.DownloadName != "" {
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", g.DownloadName))
+	}
+	if rs, ok := r.(io.ReadSeeker); ok {
+		http.ServeContent(c.Writer, c.Request, path.Base(g.Key), st.LastModified, rs)
+		return
+	}
+	c.DataFromReader(http.StatusOK, st.Size, st.ContentType, r, nil)
+}
+
+// PUT /v1/storage/object?key=&Content-Type=&expires=&signature= -> presigned PUT upload
+func (h *LocalStorageHandler) Upload(c *gin.Context) {
+	g, err := h.Store.Verify(storage.LocalOpPut, c.Query)
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	ct := c.GetHeader("Content-Type")
+	if g.ContentType != "" && ct != g.ContentType {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Content-Type does not match the signed type"})
+		return
+	}
+	if c.Request.ContentLength < 0 {
+		c.JSON(http.StatusLengthRequired, gin.H{"error": "Content-Length is required"})
+		return
+	}
+	h.put(c, g, c.Request.Body, c.Request.ContentLength, ct)
+}
+
+// POST /v1/storage/upload (multipart form from PresignPost fields + file) -> form upload
+func (h *LocalStorageHandler) UploadForm(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.MaxBytes+1<<20)
+	if err := c.Request.ParseMultipartForm(8 << 20); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid upload form"})
+		return
+	}
+	g, err := h.Store.Verify(storage.LocalOpPost, c.PostForm)
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	fh, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file field is required"})
+		return
+	}
+	if fh.Size < 1 || g.MaxBytes > 0 && fh.Size > g.MaxBytes {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file size outside the allowed range"})
+		return
+	}
+	f, err := fh.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "could not read file"})
+		return
+	}
+	defer f.Close()
+	h.put(c, g, f, fh.Size, g.ContentType)
+}
+
+// PUT /v1/storage/part?key=&uploadId=&partNumber=&expires=&signature= -> one part of a multipart upload
+func (h *LocalStorageHandler) UploadPart(c *gin.Context) {
+	g, err := h.Store.Verify(storage.LocalOpPart, c.Query)
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, maxPartSize)
+	etag, err := h.Store.PutPart(c, g.Key, g.UploadID, g.Part, body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "could not store part"})
+		return
+	}
+	c.Header("ETag", `"`+etag+`"`)
+	c.Status(http.StatusOK)
+}
+
+func (h *LocalStorageHandler) put(c *gin.Context, g *storage.LocalGrant, r io.Reader, size int64, ct string) {
+	if size > h.MaxBytes || g.MaxBytes > 0 && size > g.MaxBytes {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "file too large"})
+		return
+	}
+	if err := h.Store.Put(c, g.Key, r, size, ct); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not store file"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -1,11 +1,19 @@
 package handlers
 
 import (
+  "encoding/json"
+  "errors"
   "io"
+  "log"
   "os"
+  "strings"
 
   "github.com/gin-gonic/gin"
+  "github.com/stripe/stripe-go/v74"
   "github.com/stripe/stripe-go/v74/webhook"
+
+  "github.com/olamideolayemi/framelane-api/internal/inventory"
+  "github.com/olamideolayemi/framelane-api/internal/models"
 )
 
 func (h *PaymentsHandler) Webhook(c *gin.Context) {
@@ -15,7 +23,30 @@ func (h *PaymentsHandler) Webhook(c *gin.Context) {
 
   switch event.Type {
   case "payment_intent.succeeded":
-      // get metadata.orderId, set order status=paid, send confirmation email
+      var pi stripe.PaymentIntent
+      if err := json.Unmarshal(event.Data.Raw, &pi); err != nil { c.JSON(400, gin.H{"error": "bad payload"}); return }
+      h.markPaid(pi.Metadata["orderId"])
+      // TODO: send payment confirmation email
   }
   c.JSON(200, gin.H{"ok": true})
 }
+
+// markPaid sets the order status to Paid and reserves its stock. Payment has
+// already been taken, so a stock shortfall is logged for the workshop rather
+// than refused.
+func (h *PaymentsHandler) markPaid(orderID string) {
+  var order models.Order
+  q := h.DB.Where("id = ?", orderID)
+  if strings.HasPrefix(orderID, "FL-") { q = h.DB.Where("order_id = ?", orderID) }
+  if err := q.First(&order).Error; err != nil { log.Printf("payment for unknown order %q", orderID); return }
+
+  if err := h.DB.Model(&order).Update("status", models.OrderPaid).Error; err != nil {
+    log.Printf("order %s: mark paid: %v", order.OrderID, err)
+    return
+  }
+  if err := h.Inventory.Reserve(&order); errors.Is(err, inventory.ErrOutOfStock) {
+    log.Printf("order %s: paid but out of stock, nothing reserved", order.OrderID)
+  } else if err != nil {
+    log.Printf("order %s: reserve stock: %v", order.OrderID, err)
+  }
+}
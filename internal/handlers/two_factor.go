@@ -0,0 +1,249 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+
+	"github.com/olamideolayemi/framelane-api/internal/auth"
+	"github.com/olamideolayemi/framelane-api/internal/models"
+)
+
+const recoveryCodeCount = 10
+
+type TwoFactorHandler struct {
+	DB     *gorm.DB
+	Issuer string // shown in authenticator apps, e.g. "FrameLane"
+}
+
+// verifySecondFactor accepts either a current TOTP code or an unused recovery
+// code, and records the use so neither can be replayed.
+func verifySecondFactor(db *gorm.DB, u *models.User, code string) bool {
+	if step, ok := auth.ValidateTOTP(u.TOTPSecret, code, time.Now(), u.TOTPLastStep); ok {
+		res := db.Model(&models.User{}).
+			Where("id = ? AND totp_last_step < ?", u.ID, step).
+			Update("totp_last_step", step)
+		if res.Error != nil || res.RowsAffected == 0 {
+			return false
+		}
+		u.TOTPLastStep = step
+		return true
+	}
+
+	now := time.Now()
+	res := db.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", u.ID, auth.HashRecoveryCode(code)).
+		Update("used_at", &now)
+	return res.Error == nil && res.RowsAffected == 1
+}
+
+// replaceRecoveryCodes drops any existing codes and stores a fresh set.
+func replaceRecoveryCodes(tx *gorm.DB, userID uuid.UUID) ([]string, error) {
+	codes, err := auth.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+	rows := make([]models.RecoveryCode, len(codes))
+	for i, code := range codes {
+		rows[i] = models.RecoveryCode{ID: uuid.New(), UserID: userID, CodeHash: auth.HashRecoveryCode(code)}
+	}
+	if err := tx.Create(&rows).Error; err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func (h *TwoFactorHandler) currentUser(c *gin.Context) (*models.User, bool) {
+	uid, _ := c.Get("uid")
+	var u models.User
+	if err := h.DB.First(&u, "id = ?", uid).Error; err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
+		return nil, false
+	}
+	return &u, true
+}
+
+// POST /v1/user/2fa/enroll -> new secret + provisioning URI (not active until verified)
+func (h *TwoFactorHandler) Enroll(c *gin.Context) {
+	u, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+	if u.TOTPEnabled {
+		c.JSON(http.StatusConflict, gin.H{"error": "two-factor authentication already enabled"})
+		return
+	}
+
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate secret"})
+		return
+	}
+	if err := h.DB.Model(u).Updates(map[string]any{"totp_secret": secret, "totp_last_step": 0}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to start enrollment"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"secret":          secret,
+		"provisioningUri": auth.TOTPProvisioningURI(h.Issuer, u.Email, secret),
+	})
+}
+
+// POST /v1/user/2fa/verify -> confirms enrollment with a first code, returns recovery codes
+func (h *TwoFactorHandler) Verify(c *gin.Context) {
+	var in struct {
+		Code string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "code required"})
+		return
+	}
+	u, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+	if u.TOTPEnabled {
+		c.JSON(http.StatusConflict, gin.H{"error": "two-factor authentication already enabled"})
+		return
+	}
+	if u.TOTPSecret == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "start enrollment first"})
+		return
+	}
+	step, valid := auth.ValidateTOTP(u.TOTPSecret, in.Code, time.Now(), u.TOTPLastStep)
+	if !valid {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid code"})
+		return
+	}
+
+	var codes []string
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(u).Updates(map[string]any{"totp_enabled": true, "totp_last_step": step}).Error; err != nil {
+			return err
+		}
+		var err error
+		codes, err = replaceRecoveryCodes(tx, u.ID)
+		return err
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to enable two-factor authentication"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "two-factor authentication enabled, sign in again to continue",
+		"recoveryCodes": codes,
+	})
+}
+
+// POST /v1/user/2fa/recovery-codes -> regenerate recovery codes (needs a current code)
+func (h *TwoFactorHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	var in struct {
+		Code string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "code required"})
+		return
+	}
+	u, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+	if !u.TOTPEnabled {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "two-factor authentication not enabled"})
+		return
+	}
+	if !verifySecondFactor(h.DB, u, in.Code) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid code"})
+		return
+	}
+
+	var codes []string
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		codes, err = replaceRecoveryCodes(tx, u.ID)
+		return err
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to regenerate recovery codes"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"recoveryCodes": codes})
+}
+
+// POST /v1/user/2fa/disable -> turn off 2FA (not allowed for admins)
+func (h *TwoFactorHandler) Disable(c *gin.Context) {
+	var in struct {
+		Password string `json:"password" binding:"required"`
+		Code     string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "password and code required"})
+		return
+	}
+	u, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+	if u.IsAdmin {
+		c.JSON(http.StatusForbidden, gin.H{"error": "two-factor authentication is mandatory for admin accounts"})
+		return
+	}
+	if !u.TOTPEnabled {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "two-factor authentication not enabled"})
+		return
+	}
+	if bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(in.Password)) != nil || !verifySecondFactor(h.DB, u, in.Code) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid creds"})
+		return
+	}
+
+	if err := resetTwoFactor(h.DB, u.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to disable two-factor authentication"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "two-factor authentication disabled"})
+}
+
+// DELETE /v1/admin/users/:id/2fa (admin) -> reset a user's 2FA, e.g. lost device
+func (h *TwoFactorHandler) AdminReset(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var user models.User
+	if err := h.DB.First(&user, "id = ?", id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	if err := resetTwoFactor(h.DB, user.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset two-factor authentication"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication reset"})
+}
+
+func resetTwoFactor(db *gorm.DB, userID uuid.UUID) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.User{}).Where("id = ?", userID).
+			Updates(map[string]any{"totp_enabled": false, "totp_secret": "", "totp_last_step": 0}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error
+	})
+}
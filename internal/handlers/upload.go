@@ -1,19 +1,308 @@
 package handlers
 
 import (
+	"errors"
+	"log"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/olamideolayemi/framelane-api/internal/imaging"
+	"github.com/olamideolayemi/framelane-api/internal/models"
+	"github.com/olamideolayemi/framelane-api/internal/scan"
 	"github.com/olamideolayemi/framelane-api/internal/storage"
+	"github.com/olamideolayemi/framelane-api/internal/thumbs"
 )
 
-type UploadHandler struct{ S3 *storage.S3 }
+// uploadTTL is how long a presigned upload stays valid
+const uploadTTL = 15 * time.Minute
+
+// quarantinePrefix holds infected or unparseable uploads for review
+const quarantinePrefix = "quarantine/"
+
+// allowedImageTypes maps accepted upload MIME types to the stored file extension
+var allowedImageTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/webp": ".webp",
+	"image/tiff": ".tiff",
+}
+
+type UploadHandler struct {
+	DB       *gorm.DB
+	Store    storage.Store
+	MaxBytes int64          // largest single upload accepted
+	Thumbs   *thumbs.Worker // optional, generates resized copies after finalize
+
+	MultipartMaxBytes int64 // largest multipart upload accepted
 
+	Scanner scan.Scanner // optional malware scan on finalize
+}
+
+// userUploadKey builds a server-chosen object key under the caller's prefix,
+// so clients can't pick (and overwrite) arbitrary objects.
+func userUploadKey(uid string, ext string) string {
+	return "uploads/" + uid + "/" + uuid.NewString() + ext
+}
+
+// GET /v1/upload-url?contentType=image/jpeg (auth) -> presigned POST form for a new object
 func (h *UploadHandler) GetPresignedURL(c *gin.Context) {
-	filename := c.Query("filename")
-	if filename == "" { c.JSON(400, gin.H{"error":"filename required"}); return }
-	url, err := h.S3.PresignPut(c, filename, "", 15*time.Minute)
-	if err != nil { c.JSON(500, gin.H{"error": err.Error()}); return }
-	c.JSON(http.StatusOK, gin.H{"url": url})
+	uid, _ := c.Get("uid")
+	contentType := c.Query("contentType")
+	ext, ok := allowedImageTypes[contentType]
+	if !ok {
+		c.JSON(400, gin.H{"error": "contentType must be one of image/jpeg, image/png, image/webp, image/tiff"})
+		return
+	}
+
+	userID, err := uuid.Parse(uid.(string))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	key := userUploadKey(userID.String(), ext)
+	url, fields, err := h.Store.PresignPost(c, key, contentType, h.MaxBytes, uploadTTL)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "could not create upload URL"})
+		return
+	}
+
+	asset := models.Asset{
+		ID:          uuid.New(),
+		UserID:      userID,
+		ObjectKey:   key,
+		ContentType: contentType,
+		Status:      models.AssetPending,
+	}
+	if err := h.DB.Create(&asset).Error; err != nil {
+		c.JSON(500, gin.H{"error": "could not create upload"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"assetId":   asset.ID,
+		"key":       key,
+		"url":       url,
+		"method":    "POST",
+		"fields":    fields,
+		"maxBytes":  h.MaxBytes,
+		"expiresAt": time.Now().Add(uploadTTL),
+	})
+}
+
+// findOwnAsset loads an asset that belongs to the caller, answering 404 otherwise.
+func findOwnAsset(c *gin.Context, db *gorm.DB) (*models.Asset, bool) {
+	uid, _ := c.Get("uid")
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid upload ID"})
+		return nil, false
+	}
+	var a models.Asset
+	if err := db.First(&a, "id = ? AND user_id = ?", id, uid).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "upload not found"})
+		return nil, false
+	}
+	return &a, true
+}
+
+// GET /v1/uploads/:id (auth)
+func (h *UploadHandler) GetAsset(c *gin.Context) {
+	a, ok := findOwnAsset(c, h.DB)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, a)
+}
+
+// POST /v1/uploads/:id/finalize (auth) -> verify the uploaded object and mark the asset ready
+func (h *UploadHandler) Finalize(c *gin.Context) {
+	a, ok := findOwnAsset(c, h.DB)
+	if !ok {
+		return
+	}
+	if a.Status != models.AssetPending {
+		c.JSON(http.StatusOK, a) // already finalized, nothing to redo
+		return
+	}
+
+	// Multipart uploads have their own, larger limit and finalize on completion
+	var mu models.MultipartUpload
+	if err := h.DB.First(&mu, "asset_id = ?", a.ID).Error; err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "complete the multipart upload instead", "multipartUploadId": mu.ID})
+		return
+	}
+	h.finalize(c, a, h.MaxBytes)
+}
+
+// finalize checks the stored object is a real image of the declared type and
+// no bigger than maxBytes, then marks the asset ready.
+func (h *UploadHandler) finalize(c *gin.Context, a *models.Asset, maxBytes int64) {
+	st, err := h.Store.Stat(c, a.ObjectKey)
+	if err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "upload not found in storage, upload the file first"})
+		return
+	}
+	if st.Size > maxBytes {
+		h.reject(c, a, "file too large")
+		return
+	}
+
+	// Malware scan before anything parses the file
+	if h.Scanner != nil {
+		res, err := h.scanObject(c, a.ObjectKey)
+		if err != nil {
+			log.Printf("scan asset %s: %v", a.ID, err)
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "could not scan upload, try again shortly"})
+			return
+		}
+		if !res.Clean {
+			h.quarantine(c, a, "file failed malware scan: "+res.Signature)
+			return
+		}
+	}
+
+	obj, err := h.Store.Get(c, a.ObjectKey)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not read upload"})
+		return
+	}
+	defer obj.Close()
+
+	info, err := imaging.Inspect(obj)
+	if errors.Is(err, imaging.ErrUnknownFormat) {
+		h.quarantine(c, a, "file is not a supported image")
+		return
+	}
+	if err != nil {
+		h.quarantine(c, a, "image could not be read")
+		return
+	}
+	if info.MIME != a.ContentType {
+		h.reject(c, a, "file content does not match "+a.ContentType)
+		return
+	}
+
+	a.Size = info.Size
+	a.Width = info.Width
+	a.Height = info.Height
+	a.Orientation = info.Orientation
+	a.Checksum = info.SHA256
+	a.Status = models.AssetReady
+	if err := h.DB.Save(a).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not save upload"})
+		return
+	}
+	h.Thumbs.Enqueue(a.ID)
+	c.JSON(http.StatusOK, a)
+}
+
+func (h *UploadHandler) scanObject(c *gin.Context, key string) (scan.Result, error) {
+	obj, err := h.Store.Get(c, key)
+	if err != nil {
+		return scan.Result{}, err
+	}
+	defer obj.Close()
+	return h.Scanner.Scan(c, obj)
+}
+
+// quarantine moves a suspicious upload under quarantinePrefix, out of reach of
+// the thumbnail worker and print workflow, and rejects the asset. If the move
+// fails the asset stays pending, so the next finalize scans and tries again
+// instead of leaving the file where it was uploaded.
+func (h *UploadHandler) quarantine(c *gin.Context, a *models.Asset, reason string) {
+	key := quarantinePrefix + a.ObjectKey
+	if err := h.Store.Copy(c, a.ObjectKey, key); err != nil {
+		log.Printf("quarantine asset %s: %v", a.ID, err)
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "could not process upload, try again shortly"})
+		return
+	}
+	if err := h.Store.Delete(c, a.ObjectKey); err != nil {
+		log.Printf("quarantine asset %s: delete original: %v", a.ID, err)
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "could not process upload, try again shortly"})
+		return
+	}
+	a.QuarantineKey = key
+	log.Printf("asset %s quarantined: %s", a.ID, reason)
+	h.reject(c, a, reason)
+}
+
+func (h *UploadHandler) reject(c *gin.Context, a *models.Asset, reason string) {
+	a.Status = models.AssetRejected
+	a.RejectReason = reason
+	if err := h.DB.Save(a).Error; err != nil {
+		log.Printf("could not mark asset %s rejected: %v", a.ID, err)
+	}
+	c.JSON(http.StatusUnprocessableEntity, gin.H{"error": reason, "asset": a})
+}
+
+// QuarantinedAsset is a rejected upload held for review.
+type QuarantinedAsset struct {
+	ID           uuid.UUID `json:"id"`
+	UserID       uuid.UUID `json:"userId"`
+	ContentType  string    `json:"contentType"`
+	RejectReason string    `json:"rejectReason"`
+	RejectedAt   time.Time `json:"rejectedAt"`
+}
+
+// GET /v1/admin/quarantine (admin) -> uploads moved to quarantine, newest first
+func (h *UploadHandler) ListQuarantined(c *gin.Context) {
+	var assets []models.Asset
+	if err := h.DB.Where("status = ? AND quarantine_key <> ''", models.AssetRejected).
+		Order("updated_at DESC").Find(&assets).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	response := make([]QuarantinedAsset, len(assets))
+	for i, a := range assets {
+		response[i] = QuarantinedAsset{ID: a.ID, UserID: a.UserID, ContentType: a.ContentType, RejectReason: a.RejectReason, RejectedAt: a.UpdatedAt}
+	}
+	c.JSON(http.StatusOK, gin.H{"total": len(response), "assets": response})
+}
+
+// findQuarantined loads a quarantined asset by ID, answering 404 otherwise.
+func (h *UploadHandler) findQuarantined(c *gin.Context) (*models.Asset, bool) {
+	var a models.Asset
+	if err := h.DB.First(&a, "id = ? AND status = ? AND quarantine_key <> ''", c.Param("id"), models.AssetRejected).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "quarantined upload not found"})
+		return nil, false
+	}
+	return &a, true
+}
+
+// GET /v1/admin/quarantine/:id/download (admin) -> redirect to a one-off download of the quarantined file
+func (h *UploadHandler) DownloadQuarantined(c *gin.Context) {
+	a, ok := h.findQuarantined(c)
+	if !ok {
+		return
+	}
+	// Never served inline: the file may be malware
+	u, err := h.Store.PresignGet(c, a.QuarantineKey, a.ID.String()+".quarantined", downloadURLTTL)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not create download link"})
+		return
+	}
+	log.Printf("admin %s downloaded quarantined asset %s", c.GetString("uid"), a.ID)
+	c.Redirect(http.StatusFound, u)
+}
+
+// DELETE /v1/admin/quarantine/:id (admin) -> delete the quarantined file now
+func (h *UploadHandler) DeleteQuarantined(c *gin.Context) {
+	a, ok := h.findQuarantined(c)
+	if !ok {
+		return
+	}
+	if err := h.Store.Delete(c, a.QuarantineKey); err != nil && !errors.Is(err, storage.ErrNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not delete file"})
+		return
+	}
+	if err := h.DB.Model(a).Update("quarantine_key", "").Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not update upload"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Quarantined file deleted"})
 }
@@ -3,6 +3,7 @@ package handlers
 import (
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -10,11 +11,13 @@ import (
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 
+	"github.com/olamideolayemi/framelane-api/internal/auth"
 	"github.com/olamideolayemi/framelane-api/internal/models"
 )
 
 type UsersHandler struct {
-	DB *gorm.DB
+	DB       *gorm.DB
+	Throttle *auth.Throttle
 }
 
 // UserResponse is a safe representation of user data for API responses
@@ -232,3 +235,85 @@ func (h *UsersHandler) UpdateUserProfile(c *gin.Context) {
 		"user":    response,
 	})
 }
+
+// LockedUserResponse pairs a locked account with its lockout details
+type LockedUserResponse struct {
+	ID          *uuid.UUID `json:"id"` // nil when the email has no account
+	Email       string     `json:"email"`
+	Name        string     `json:"name"`
+	LockedUntil time.Time  `json:"locked_until"`
+	LastFailure time.Time  `json:"last_failure_at"`
+}
+
+// List accounts currently locked out by failed sign-ins
+func (h *UsersHandler) ListLockedUsers(c *gin.Context) {
+	var rows []models.LoginThrottle
+	if err := h.DB.Where("key LIKE ? AND locked_until > ?", "acct:%", time.Now()).
+		Order("locked_until DESC").Find(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	emails := make([]string, len(rows))
+	for i, r := range rows {
+		emails[i] = strings.TrimPrefix(r.Key, "acct:")
+	}
+	var users []models.User
+	if len(emails) > 0 {
+		if err := h.DB.Where("email IN ?", emails).Find(&users).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
+		}
+	}
+	byEmail := make(map[string]models.User, len(users))
+	for _, u := range users {
+		byEmail[u.Email] = u
+	}
+
+	response := make([]LockedUserResponse, len(rows))
+	for i, r := range rows {
+		lr := LockedUserResponse{Email: emails[i], LockedUntil: *r.LockedUntil, LastFailure: r.LastFailureAt}
+		if u, ok := byEmail[emails[i]]; ok {
+			id := u.ID
+			lr.ID = &id
+			lr.Name = u.Name
+		}
+		response[i] = lr
+	}
+
+	c.JSON(http.StatusOK, gin.H{"total": len(response), "users": response})
+}
+
+// Clear a user's sign-in lockout
+func (h *UsersHandler) UnlockUser(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var user models.User
+	if err := h.DB.First(&user, "id = ?", id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	if h.Throttle != nil {
+		cleared, err := h.Throttle.Unlock(user.Email)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unlock user"})
+			return
+		}
+		if !cleared {
+			c.JSON(http.StatusOK, gin.H{"message": "User was not locked"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "User unlocked successfully"})
+}
@@ -0,0 +1,42 @@
+package imaging
+
+import (
+	"image"
+	"math"
+
+	"golang.org/x/image/draw"
+)
+
+// Rotate turns an upright image clockwise by 0, 90, 180 or 270 degrees.
+func Rotate(img image.Image, degrees int) image.Image {
+	switch degrees {
+	case 90:
+		return Orient(img, 6)
+	case 180:
+		return Orient(img, 3)
+	case 270:
+		return Orient(img, 8)
+	}
+	return img
+}
+
+// RenderPrint cuts crop out of img at full resolution. With fill the crop is
+// the print; with border it is centred on a white canvas with the print's
+// aspect ratio (widthIn x heightIn).
+func RenderPrint(img image.Image, crop image.Rectangle, widthIn, heightIn float64, border bool) image.Image {
+	crop = crop.Add(img.Bounds().Min)
+	cw, ch := crop.Dx(), crop.Dy()
+	w, h := cw, ch
+	if border {
+		if float64(cw)/float64(ch) > widthIn/heightIn {
+			h = int(math.Round(float64(cw) * heightIn / widthIn))
+		} else {
+			w = int(math.Round(float64(ch) * widthIn / heightIn))
+		}
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
+	at := image.Pt((w-cw)/2, (h-ch)/2)
+	draw.Draw(dst, image.Rectangle{Min: at, Max: at.Add(image.Pt(cw, ch))}, img, crop.Min, draw.Over)
+	return dst
+}
@@ -0,0 +1,74 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// Orientation returns the EXIF orientation (1-8) of a JPEG or TIFF file, or 1
+// when there is none. Only IFD0 is read, which is where cameras put it.
+func Orientation(data []byte) int {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xFF, 0xD8}):
+		return jpegOrientation(data)
+	case bytes.HasPrefix(data, []byte("II*\x00")), bytes.HasPrefix(data, []byte("MM\x00*")):
+		return tiffOrientation(data)
+	}
+	return 1
+}
+
+func jpegOrientation(data []byte) int {
+	i := 2
+	for i+4 <= len(data) {
+		if data[i] != 0xFF {
+			return 1
+		}
+		marker := data[i+1]
+		if marker == 0xDA || marker == 0xD9 { // start of scan / end of image: no EXIF before pixels
+			return 1
+		}
+		n := int(binary.BigEndian.Uint16(data[i+2 : i+4]))
+		if n < 2 || i+2+n > len(data) {
+			return 1
+		}
+		seg := data[i+4 : i+2+n]
+		if marker == 0xE1 && bytes.HasPrefix(seg, []byte("Exif\x00\x00")) {
+			return tiffOrientation(seg[6:])
+		}
+		i += 2 + n
+	}
+	return 1
+}
+
+func tiffOrientation(t []byte) int {
+	if len(t) < 8 {
+		return 1
+	}
+	var bo binary.ByteOrder
+	switch string(t[:2]) {
+	case "II":
+		bo = binary.LittleEndian
+	case "MM":
+		bo = binary.BigEndian
+	default:
+		return 1
+	}
+	ifd := int(bo.Uint32(t[4:8]))
+	if ifd+2 > len(t) {
+		return 1
+	}
+	count := int(bo.Uint16(t[ifd : ifd+2]))
+	for e := 0; e < count; e++ {
+		off := ifd + 2 + e*12
+		if off+12 > len(t) {
+			return 1
+		}
+		if bo.Uint16(t[off:off+2]) == 0x0112 {
+			if o := int(bo.Uint16(t[off+8 : off+10])); o >= 1 && o <= 8 {
+				return o
+			}
+			return 1
+		}
+	}
+	return 1
+}
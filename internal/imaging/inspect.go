@@ -0,0 +1,87 @@
+package imaging
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+
+	_ "golang.org/x/image/tiff"
+	_ "golang.org/x/image/webp"
+)
+
+var ErrUnknownFormat = errors.New("not a supported image format")
+
+// Info describes an uploaded image file.
+type Info struct {
+	MIME        string
+	Width       int // as stored, before EXIF orientation
+	Height      int
+	Orientation int // EXIF orientation, 1 when absent
+	Size        int64
+	SHA256      string
+}
+
+// Sniff identifies the image type from its magic bytes. It returns "" for
+// anything that isn't JPEG, PNG, WebP or TIFF.
+func Sniff(head []byte) string {
+	switch {
+	case bytes.HasPrefix(head, []byte{0xFF, 0xD8, 0xFF}):
+		return "image/jpeg"
+	case bytes.HasPrefix(head, []byte("\x89PNG\r\n\x1a\n")):
+		return "image/png"
+	case len(head) >= 12 && bytes.Equal(head[:4], []byte("RIFF")) && bytes.Equal(head[8:12], []byte("WEBP")):
+		return "image/webp"
+	case bytes.HasPrefix(head, []byte("II*\x00")), bytes.HasPrefix(head, []byte("MM\x00*")):
+		return "image/tiff"
+	}
+	return ""
+}
+
+// Inspect reads r to the end, checking the magic bytes, decoding the pixel
+// dimensions from the header and hashing the whole stream.
+func Inspect(r io.Reader) (*Info, error) {
+	h := sha256.New()
+	counter := &countingWriter{}
+	br := bufio.NewReaderSize(io.TeeReader(r, io.MultiWriter(h, counter)), 64<<10)
+
+	// EXIF sits near the start; a short file just gives a shorter peek
+	head, err := br.Peek(64 << 10)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, err
+	}
+	mime := Sniff(head)
+	if mime == "" {
+		return nil, ErrUnknownFormat
+	}
+	orientation := Orientation(head)
+
+	cfg, _, err := image.DecodeConfig(br)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.Copy(io.Discard, br); err != nil {
+		return nil, err
+	}
+
+	return &Info{
+		MIME:        mime,
+		Width:       cfg.Width,
+		Height:      cfg.Height,
+		Orientation: orientation,
+		Size:        counter.n,
+		SHA256:      hex.EncodeToString(h.Sum(nil)),
+	}, nil
+}
+
+type countingWriter struct{ n int64 }
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	return len(p), nil
+}
@@ -0,0 +1,107 @@
+package imaging
+
+import (
+	"image"
+	"image/color"
+
+	"golang.org/x/image/draw"
+)
+
+// White is the default mat colour.
+var White = color.RGBA{255, 255, 255, 255}
+
+// MockupOptions describe how a photo is framed in a preview.
+type MockupOptions struct {
+	PrintWidthIn  float64 // visible print area, already matched to the photo orientation
+	PrintHeightIn float64
+	FrameWidthIn  float64     // moulding width
+	FrameColor    color.Color // used when Texture is nil
+	Texture       image.Image // optional tile for the moulding
+	MatWidthIn    float64     // 0 for no mat
+	MatColor      color.Color
+	LongSidePx    int // size of the rendered preview
+}
+
+// RenderMockup draws photo, cropped to the print's aspect ratio, inside a mat
+// and frame moulding.
+func RenderMockup(photo image.Image, o MockupOptions) image.Image {
+	totalW := o.PrintWidthIn + 2*(o.MatWidthIn+o.FrameWidthIn)
+	totalH := o.PrintHeightIn + 2*(o.MatWidthIn+o.FrameWidthIn)
+	ppi := float64(o.LongSidePx) / max(totalW, totalH)
+	px := func(in float64) int { return int(in*ppi + 0.5) }
+
+	canvas := image.NewRGBA(image.Rect(0, 0, px(totalW), px(totalH)))
+
+	// Moulding: texture tiles or a flat colour, with a darker inner edge for depth
+	if o.Texture != nil {
+		tb := o.Texture.Bounds()
+		for y := 0; y < canvas.Bounds().Dy(); y += tb.Dy() {
+			for x := 0; x < canvas.Bounds().Dx(); x += tb.Dx() {
+				draw.Draw(canvas, image.Rect(x, y, x+tb.Dx(), y+tb.Dy()), o.Texture, tb.Min, draw.Src)
+			}
+		}
+	} else {
+		draw.Draw(canvas, canvas.Bounds(), image.NewUniform(o.FrameColor), image.Point{}, draw.Src)
+	}
+	fw := px(o.FrameWidthIn)
+	inner := canvas.Bounds().Inset(fw)
+	shadow := max(1, fw/10)
+	draw.Draw(canvas, inner.Inset(-shadow), image.NewUniform(color.RGBA{0, 0, 0, 90}), image.Point{}, draw.Over)
+
+	// Mat
+	printRect := inner.Inset(px(o.MatWidthIn))
+	if o.MatWidthIn > 0 {
+		draw.Draw(canvas, inner, image.NewUniform(o.MatColor), image.Point{}, draw.Src)
+	}
+
+	// Photo, centre-cropped to the print aspect ratio
+	src := CoverCrop(photo.Bounds(), printRect.Dx(), printRect.Dy())
+	draw.CatmullRom.Scale(canvas, printRect, photo, src, draw.Src, nil)
+	return canvas
+}
+
+// CoverCrop returns the largest centred sub-rectangle of b with the aspect
+// ratio w:h, i.e. the part of the image that fills a w x h print.
+func CoverCrop(b image.Rectangle, w, h int) image.Rectangle {
+	bw, bh := b.Dx(), b.Dy()
+	if bw*h > bh*w { // image is wider than the print: trim the sides
+		cw := bh * w / h
+		x := b.Min.X + (bw-cw)/2
+		return image.Rect(x, b.Min.Y, x+cw, b.Max.Y)
+	}
+	ch := bw * h / w
+	y := b.Min.Y + (bh-ch)/2
+	return image.Rect(b.Min.X, y, b.Max.X, y+ch)
+}
+
+// ParseHexColor reads "#rrggbb" or "rrggbb".
+func ParseHexColor(s string) (color.RGBA, bool) {
+	if len(s) > 0 && s[0] == '#' {
+		s = s[1:]
+	}
+	if len(s) != 6 {
+		return color.RGBA{}, false
+	}
+	var v [3]uint8
+	for i := 0; i < 3; i++ {
+		hi, ok1 := hexNibble(s[2*i])
+		lo, ok2 := hexNibble(s[2*i+1])
+		if !ok1 || !ok2 {
+			return color.RGBA{}, false
+		}
+		v[i] = hi<<4 | lo
+	}
+	return color.RGBA{v[0], v[1], v[2], 255}, true
+}
+
+func hexNibble(c byte) (uint8, bool) {
+	switch {
+	case c >= '0' && c <= '9':
+		return c - '0', true
+	case c >= 'a' && c <= 'f':
+		return c - 'a' + 10, true
+	case c >= 'A' && c <= 'F':
+		return c - 'A' + 10, true
+	}
+	return 0, false
+}
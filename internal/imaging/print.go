@@ -0,0 +1,41 @@
+package imaging
+
+import "math"
+
+// Print quality verdicts
+const (
+	PrintOK      = "ok"
+	PrintWarning = "warning"
+	PrintReject  = "reject"
+)
+
+// PrintCheck is the outcome of comparing an image against a print size.
+type PrintCheck struct {
+	Verdict string  `json:"verdict"`
+	DPI     int     `json:"dpi"` // effective pixels per inch on the print
+	WarnDPI int     `json:"warnDpi"`
+	MinDPI  int     `json:"minDpi"`
+	Message string  `json:"message,omitempty"`
+	Width   float64 `json:"printWidthIn"`
+	Height  float64 `json:"printHeightIn"`
+}
+
+// CheckPrint works out the effective DPI of a widthPx x heightPx image printed
+// at widthIn x heightIn. The image is assumed to be rotated to match the
+// print's orientation, and cropped to fill it, so the tighter side decides.
+func CheckPrint(widthPx, heightPx int, widthIn, heightIn float64, warnDPI, minDPI int) PrintCheck {
+	longPx, shortPx := float64(max(widthPx, heightPx)), float64(min(widthPx, heightPx))
+	longIn, shortIn := math.Max(widthIn, heightIn), math.Min(widthIn, heightIn)
+
+	dpi := int(math.Floor(math.Min(longPx/longIn, shortPx/shortIn)))
+	pc := PrintCheck{DPI: dpi, WarnDPI: warnDPI, MinDPI: minDPI, Width: widthIn, Height: heightIn, Verdict: PrintOK}
+	switch {
+	case dpi < minDPI:
+		pc.Verdict = PrintReject
+		pc.Message = "image resolution is too low for this size, choose a smaller size or a higher resolution photo"
+	case dpi < warnDPI:
+		pc.Verdict = PrintWarning
+		pc.Message = "image may look soft at this size"
+	}
+	return pc
+}
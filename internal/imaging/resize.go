@@ -0,0 +1,136 @@
+package imaging
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"image"
+	"image/jpeg"
+	"io"
+
+	"golang.org/x/image/draw"
+)
+
+// ErrTooManyPixels is returned for images larger than the decode limit.
+var ErrTooManyPixels = errors.New("image has too many pixels to decode")
+
+// CheckPixels refuses width x height images over maxPixels. Decoded images
+// take 4 to 8 bytes a pixel, so this bounds memory, unlike the file size.
+func CheckPixels(width, height, maxPixels int) error {
+	if maxPixels > 0 && int64(width)*int64(height) > int64(maxPixels) {
+		return ErrTooManyPixels
+	}
+	return nil
+}
+
+// Decode reads an image from r, refusing it from the header alone if it has
+// more than maxPixels, and turns it upright according to its EXIF orientation.
+// JPEG, PNG and WebP are decoded as they stream; TIFF keeps its directory
+// anywhere in the file, so it is read into memory once.
+func Decode(r io.Reader, maxPixels int) (image.Image, error) {
+	br := bufio.NewReaderSize(r, 64<<10)
+	head, err := br.Peek(64 << 10)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, err
+	}
+	orientation := Orientation(head)
+
+	var src io.Reader
+	var cfg image.Config
+	if Sniff(head) == "image/tiff" {
+		data, err := io.ReadAll(br)
+		if err != nil {
+			return nil, err
+		}
+		if cfg, _, err = image.DecodeConfig(bytes.NewReader(data)); err != nil {
+			return nil, err
+		}
+		src = bytes.NewReader(data)
+	} else {
+		var header bytes.Buffer
+		if cfg, _, err = image.DecodeConfig(io.TeeReader(br, &header)); err != nil {
+			return nil, err
+		}
+		src = io.MultiReader(&header, br)
+	}
+	if err := CheckPixels(cfg.Width, cfg.Height, maxPixels); err != nil {
+		return nil, err
+	}
+
+	img, _, err := image.Decode(src)
+	if err != nil {
+		return nil, err
+	}
+	return Orient(img, orientation), nil
+}
+
+// Fit scales img down so its longest side is at most maxSide, flattening any
+// transparency onto white. Smaller opaque images are returned as they are.
+func Fit(img image.Image, maxSide int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= maxSide && h <= maxSide {
+		if o, ok := img.(interface{ Opaque() bool }); ok && o.Opaque() {
+			return img
+		}
+		maxSide = max(w, h) // keep the size, just flatten
+	}
+	if w >= h {
+		h = max(1, h*maxSide/w)
+		w = maxSide
+	} else {
+		w = max(1, w*maxSide/h)
+		h = maxSide
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, b, draw.Over, nil)
+	return dst
+}
+
+// Orient applies an EXIF orientation (1-8) so the image displays upright.
+func Orient(img image.Image, o int) image.Image {
+	if o <= 1 || o > 8 {
+		return img
+	}
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	swap := o >= 5 // orientations 5-8 transpose the axes
+	dw, dh := w, h
+	if swap {
+		dw, dh = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch o {
+			case 2: // mirror horizontal
+				dx, dy = w-1-x, y
+			case 3: // rotate 180
+				dx, dy = w-1-x, h-1-y
+			case 4: // mirror vertical
+				dx, dy = x, h-1-y
+			case 5: // transpose
+				dx, dy = y, x
+			case 6: // rotate 90 CW
+				dx, dy = h-1-y, x
+			case 7: // transverse
+				dx, dy = h-1-y, w-1-x
+			case 8: // rotate 90 CCW
+				dx, dy = y, w-1-x
+			}
+			dst.Set(dx, dy, img.At(b.Min.X+x, b.Min.Y+y))
+		}
+	}
+	return dst
+}
+
+// EncodeJPEG encodes img as a JPEG at the given quality.
+func EncodeJPEG(img image.Image, quality int) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,205 @@
+// Package inventory tracks moulding stock per frame and size through the
+// order lifecycle: reserved when paid, consumed in production, released on
+// cancellation.
+package inventory
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/olamideolayemi/framelane-api/internal/email"
+	"github.com/olamideolayemi/framelane-api/internal/models"
+)
+
+// ErrOutOfStock is returned when a tracked frame and size has nothing left.
+var ErrOutOfStock = errors.New("out of stock")
+
+type Service struct {
+	DB    *gorm.DB
+	Email *email.Sender // optional, low-stock alerts go to admin users
+}
+
+// Available returns how many of a frame and size can still be ordered.
+// tracked is false for combinations without a stock row.
+func (s *Service) Available(frameID, sizeID uuid.UUID) (n int, tracked bool, err error) {
+	var item models.StockItem
+	err = s.DB.First(&item, "frame_id = ? AND size_id = ?", frameID, sizeID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return item.Available(), true, nil
+}
+
+// Stock is a snapshot of which tracked frame and size combinations have
+// nothing left, for marking catalogue listings.
+type Stock struct {
+	out map[[2]uuid.UUID]bool
+}
+
+// Stock loads the combinations that are currently out of stock.
+func (s *Service) Stock() (*Stock, error) {
+	var items []models.StockItem
+	if err := s.DB.Where("on_hand - reserved < 1").Find(&items).Error; err != nil {
+		return nil, err
+	}
+	st := &Stock{out: make(map[[2]uuid.UUID]bool, len(items))}
+	for _, it := range items {
+		if it.Available() < 1 {
+			st.out[[2]uuid.UUID{it.FrameID, it.SizeID}] = true
+		}
+	}
+	return st, nil
+}
+
+// InStock reports whether a frame and size can be ordered as far as stock
+// goes. Untracked combinations are always in stock.
+func (st *Stock) InStock(frameID, sizeID uuid.UUID) bool {
+	return !st.out[[2]uuid.UUID{frameID, sizeID}]
+}
+
+// AnyInStock reports whether any of the frames is in stock in any of the
+// sizes.
+func (st *Stock) AnyInStock(frameIDs, sizeIDs []uuid.UUID) bool {
+	for _, f := range frameIDs {
+		for _, sz := range sizeIDs {
+			if st.InStock(f, sz) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Reserve holds one unit for a paid order.
+func (s *Service) Reserve(o *models.Order) error {
+	return s.Transition(o, models.OrderPaid, nil)
+}
+
+// Transition moves stock for an order entering status, a canonical order
+// status, and runs then (usually the status update itself) in the same
+// transaction, so neither is recorded without the other:
+//   - Paid holds a unit, unless the order already holds or used one.
+//   - In Production takes a unit off the shelf, using the reservation if any.
+//   - Cancelled gives a reservation back.
+//
+// Untracked combinations move no stock and get no stock state.
+func (s *Service) Transition(o *models.Order, status string, then func(tx *gorm.DB) error) error {
+	prev := o.StockState
+	var itemID uuid.UUID
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if itemID, err = move(tx, o, status); err != nil {
+			return err
+		}
+		if then != nil {
+			return then(tx)
+		}
+		return nil
+	})
+	if err != nil {
+		o.StockState = prev
+		return err
+	}
+	if itemID != uuid.Nil {
+		s.Changed(itemID)
+	}
+	return nil
+}
+
+// move applies the stock change for status to the order's stock row and
+// records the new state on the order, returning the row it changed.
+func move(tx *gorm.DB, o *models.Order, status string) (uuid.UUID, error) {
+	var state, set, guard string
+	switch {
+	case status == models.OrderPaid && (o.StockState == "" || o.StockState == models.StockReleased):
+		state, set, guard = models.StockReserved, "reserved = reserved + 1", "on_hand - reserved >= 1"
+	case status == models.OrderInProduction && o.StockState == models.StockReserved:
+		state, set, guard = models.StockConsumed, "on_hand = on_hand - 1, reserved = reserved - 1", "reserved >= 1"
+	case status == models.OrderInProduction && (o.StockState == "" || o.StockState == models.StockReleased):
+		state, set, guard = models.StockConsumed, "on_hand = on_hand - 1", "on_hand - reserved >= 1"
+	case status == models.OrderCancelled && o.StockState == models.StockReserved:
+		state, set, guard = models.StockReleased, "reserved = reserved - 1", "reserved >= 1"
+	default:
+		return uuid.Nil, nil
+	}
+
+	var item models.StockItem
+	err := tx.First(&item, "frame_id = ? AND size_id = ?", o.FrameID, o.SizeID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return uuid.Nil, nil
+	}
+	if err != nil {
+		return uuid.Nil, err
+	}
+	res := tx.Exec("UPDATE stock_items SET "+set+", updated_at = ? WHERE id = ? AND "+guard, time.Now(), item.ID)
+	if res.Error != nil {
+		return uuid.Nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return uuid.Nil, ErrOutOfStock
+	}
+	if err := tx.Model(o).Update("stock_state", state).Error; err != nil {
+		return uuid.Nil, err
+	}
+	o.StockState = state
+	return item.ID, nil
+}
+
+// Changed alerts admins the first time a stock row drops to its low-stock
+// threshold. Availability is per frame and size, so the frame and size
+// status flags stay as admins set them.
+func (s *Service) Changed(itemID uuid.UUID) {
+	var item models.StockItem
+	if err := s.DB.Preload("Frame", models.WithArchived).Preload("Size", models.WithArchived).First(&item, "id = ?", itemID).Error; err != nil {
+		return
+	}
+	if !item.Low() {
+		if item.LowStockNotifiedAt != nil {
+			s.DB.Model(&item).Update("low_stock_notified_at", nil)
+		}
+		return
+	}
+	res := s.DB.Model(&models.StockItem{}).
+		Where("id = ? AND low_stock_notified_at IS NULL", item.ID).
+		Update("low_stock_notified_at", time.Now())
+	if res.Error == nil && res.RowsAffected == 1 {
+		go s.alertLowStock(item)
+	}
+}
+
+func (s *Service) alertLowStock(item models.StockItem) {
+	if s.Email == nil {
+		return
+	}
+	var admins []models.User
+	if err := s.DB.Where("is_admin = ? AND email <> ''", true).Find(&admins).Error; err != nil {
+		log.Printf("inventory: low stock alert: %v", err)
+		return
+	}
+	data := map[string]string{
+		"Frame":     item.Frame.Name,
+		"Size":      item.Size.Name,
+		"Available": fmt.Sprintf("%d", item.Available()),
+		"Threshold": fmt.Sprintf("%d", item.LowStockThreshold),
+		"Year":      fmt.Sprintf("%d", time.Now().Year()),
+	}
+	body, err := email.ParseTemplate("low_stock.html", data)
+	if err != nil {
+		log.Printf("inventory: low stock alert: %v", err)
+		return
+	}
+	subject := fmt.Sprintf("Low stock: %s %s", item.Frame.Name, item.Size.Name)
+	for _, a := range admins {
+		if err := s.Email.Send(a.Email, subject, body); err != nil {
+			log.Printf("inventory: low stock alert to %s: %v", a.Email, err)
+		}
+	}
+}
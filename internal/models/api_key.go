@@ -0,0 +1,57 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// API key scopes
+const (
+	ScopeOrdersRead  = "orders:read"
+	ScopeOrdersWrite = "orders:write"
+)
+
+// APIKey lets a partner system act as UserID without a human login. Only a
+// hash of the secret is stored; Prefix identifies the key in lookups and logs.
+type APIKey struct {
+	ID          uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	Name        string     `gorm:"size:120;not null" json:"name"`
+	Prefix      string     `gorm:"size:16;uniqueIndex" json:"prefix"`
+	KeyHash     string     `gorm:"size:64;not null" json:"-"`
+	UserID      uuid.UUID  `gorm:"type:uuid;index" json:"userId"`
+	User        User       `gorm:"foreignKey:UserID" json:"-"`
+	Scopes      string     `gorm:"size:400" json:"-"`                    // comma separated
+	RateLimit   int        `gorm:"not null;default:60" json:"rateLimit"` // requests per minute
+	LastUsedAt  *time.Time `json:"lastUsedAt"`
+	ExpiresAt   *time.Time `json:"expiresAt"`
+	RevokedAt   *time.Time `json:"revokedAt"`
+	RotatedFrom *uuid.UUID `gorm:"type:uuid" json:"rotatedFrom,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+}
+
+func (k *APIKey) ScopeList() []string {
+	if k.Scopes == "" {
+		return []string{}
+	}
+	return strings.Split(k.Scopes, ",")
+}
+
+func (k *APIKey) HasScope(scope string) bool {
+	for _, s := range k.ScopeList() {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Usable reports whether the key may authenticate right now.
+func (k *APIKey) Usable(now time.Time) bool {
+	if k.RevokedAt != nil {
+		return false
+	}
+	return k.ExpiresAt == nil || k.ExpiresAt.After(now)
+}
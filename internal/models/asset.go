@@ -0,0 +1,58 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Thumbnail statuses
+const (
+	ThumbsPending = "pending"
+	ThumbsDone    = "done"
+	ThumbsFailed  = "failed"
+)
+
+// Asset statuses
+const (
+	AssetPending  = "pending"  // upload URL issued, object not verified yet
+	AssetReady    = "ready"    // verified image, can be used in an order
+	AssetRejected = "rejected" // failed verification, see RejectReason
+	AssetPurged   = "purged"   // image files deleted under the retention policy
+)
+
+// Asset is an uploaded customer image. Orders reference an asset instead of
+// a raw URL so we know the object exists, belongs to the caller and is a
+// real image.
+type Asset struct {
+	ID            uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	UserID        uuid.UUID `gorm:"type:uuid;index" json:"userId"`
+	ObjectKey     string    `gorm:"size:300;uniqueIndex" json:"key"`
+	ContentType   string    `gorm:"size:60" json:"contentType"`
+	Size          int64     `json:"size"`
+	Width         int       `json:"width"`
+	Height        int       `json:"height"`
+	Orientation   int       `gorm:"default:1" json:"orientation"` // EXIF orientation of the original
+	Checksum      string    `gorm:"size:64" json:"checksum"`      // sha256, hex
+	Status        string    `gorm:"size:20;default:'pending';index" json:"status"`
+	RejectReason  string    `gorm:"size:200" json:"rejectReason,omitempty"`
+	QuarantineKey string    `gorm:"size:320" json:"-"` // where a rejected file was moved for review
+
+	// Resized JPEG copies stored next to the original, filled in by the thumbnail worker
+	ThumbStatus   string     `gorm:"size:20;index" json:"thumbStatus"` // "", pending, done, failed
+	ThumbAttempts int        `gorm:"not null;default:0" json:"-"`
+	ThumbKey      string     `gorm:"size:300" json:"thumbKey,omitempty"`   // 256px
+	MediumKey     string     `gorm:"size:300" json:"mediumKey,omitempty"`  // 800px
+	PreviewKey    string     `gorm:"size:300" json:"previewKey,omitempty"` // 1600px
+	PurgedAt      *time.Time `json:"purgedAt,omitempty"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	UpdatedAt     time.Time  `json:"updatedAt"`
+}
+
+// UprightSize is the pixel size once EXIF orientation is applied.
+func (a *Asset) UprightSize() (width, height int) {
+	if a.Orientation >= 5 {
+		return a.Height, a.Width
+	}
+	return a.Width, a.Height
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CustomSizeQuote prices a frame in dimensions the catalogue doesn't list.
+// Ordering it creates a hidden custom FrameSize (SizeID) at the quoted price.
+type CustomSizeQuote struct {
+	ID        uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	UserID    uuid.UUID  `gorm:"type:uuid;index" json:"userId"`
+	FrameID   uuid.UUID  `gorm:"type:uuid" json:"frameId"`
+	Width     float64    `json:"width"` // as entered, in Unit
+	Height    float64    `json:"height"`
+	Unit      string     `gorm:"size:4" json:"unit"`
+	WidthIn   float64    `json:"widthIn"`
+	HeightIn  float64    `json:"heightIn"`
+	Price     int        `gorm:"not null" json:"price"`
+	ExpiresAt time.Time  `json:"expiresAt"`
+	SizeID    *uuid.UUID `gorm:"type:uuid" json:"sizeId,omitempty"`  // set when first ordered
+	OrderID   *uuid.UUID `gorm:"type:uuid" json:"orderId,omitempty"` // set once ordered; a quote is used once
+	CreatedAt time.Time  `json:"createdAt"`
+}
+
+// Expired reports whether the quote can no longer be ordered.
+func (q *CustomSizeQuote) Expired(now time.Time) bool {
+	return now.After(q.ExpiresAt)
+}
@@ -1,32 +1,244 @@
 package models
 
 import (
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/google/uuid"
 	"gorm.io/gorm"
-	"time"
 )
 
 type FrameSize struct {
-	ID        uuid.UUID      `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
-	Name      string         `gorm:"unique;not null" json:"name"`
-	Price     int            `gorm:"not null" json:"price"`
-	Status    string         `gorm:"default:'available'" json:"status"` // "available" or "out_of_stock"
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+	ID           uuid.UUID      `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	Name         string         `gorm:"unique;not null" json:"name"`
+	Price        int            `gorm:"not null" json:"price"`
+	Status       string         `gorm:"default:'available'" json:"status"` // "available" or "out_of_stock"
+	Width        float64        `json:"width"`                             // as listed, in Unit
+	Height       float64        `json:"height"`
+	Unit         string         `gorm:"size:4;default:'in'" json:"unit"`  // "in", "cm" or "mm"
+	Orientation  string         `gorm:"size:10;index" json:"orientation"` // portrait, landscape or square, from the listed dimensions
+	DisplayOrder int            `gorm:"default:0;index" json:"displayOrder"`
+	Custom       bool           `gorm:"not null;default:false;index" json:"custom"` // made for a custom size quote, hidden from the catalogue
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"`
 }
 
+// Size units
+const (
+	UnitInch = "in"
+	UnitCM   = "cm"
+	UnitMM   = "mm"
+)
+
+// Orientations
+const (
+	Portrait  = "portrait"
+	Landscape = "landscape"
+	Square    = "square"
+)
+
 type Frame struct {
-	ID        uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4()" json:"id"`
-	Name      string    `gorm:"size:80;uniqueIndex" json:"name"` // e.g., "Wooden Frame"
-	Status    string    `gorm:"size:40;default:'available'" json:"status"`
-	CreatedAt time.Time
-	UpdatedAt time.Time
+	ID              uuid.UUID    `gorm:"type:uuid;default:uuid_generate_v4()" json:"id"`
+	Name            string       `gorm:"size:80;uniqueIndex" json:"name"` // e.g., "Wooden Frame"
+	Slug            string       `gorm:"size:100;uniqueIndex" json:"slug"`
+	Status          string       `gorm:"size:40;default:'available'" json:"status"`
+	Description     string       `gorm:"type:text" json:"description"`
+	Material        string       `gorm:"size:40;index" json:"material"` // e.g. "oak", "aluminium"
+	Color           string       `gorm:"size:40;index" json:"color"`
+	MouldingWidthIn float64      `json:"mouldingWidthIn"`
+	DisplayOrder    int          `gorm:"default:0;index" json:"displayOrder"`
+	Images          []FrameImage `gorm:"foreignKey:FrameID;constraint:OnDelete:CASCADE" json:"-"`
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	DeletedAt       gorm.DeletedAt `gorm:"index" json:"-"` // set while archived
+}
+
+// WithArchived is a Preload scope that also finds archived frames and sizes,
+// so orders keep resolving what they were placed with.
+func WithArchived(db *gorm.DB) *gorm.DB {
+	return db.Unscoped()
+}
+
+// NextSizeOrder is the display order for a new size: after every existing
+// one, archived sizes included so a restore doesn't collide.
+func NextSizeOrder(db *gorm.DB) (int, error) {
+	var last int
+	err := db.Unscoped().Model(&FrameSize{}).Select("COALESCE(MAX(display_order), 0)").Scan(&last).Error
+	return last + 10, err
+}
+
+// ArchivedAt is when a row was archived, or nil.
+func ArchivedAt(d gorm.DeletedAt) *time.Time {
+	if !d.Valid {
+		return nil
+	}
+	t := d.Time
+	return &t
+}
+
+// FrameImage is one product photo in a frame's gallery.
+type FrameImage struct {
+	ID        uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	FrameID   uuid.UUID `gorm:"type:uuid;index" json:"frameId"`
+	Key       string    `gorm:"size:300" json:"-"`
+	Alt       string    `gorm:"size:200" json:"alt"`
+	Position  int       `json:"position"`
+	CreatedAt time.Time `json:"createdAt"`
 }
 
 // FrameResponse can be used in APIs to safely return frame info
 type FrameResponse struct {
-	ID     uuid.UUID `json:"id"`
-	Name   string    `json:"name"`
-	Status string    `json:"status"`
+	ID              uuid.UUID            `json:"id"`
+	Name            string               `json:"name"`
+	Slug            string               `json:"slug"`
+	Status          string               `json:"status"`
+	Description     string               `json:"description"`
+	Material        string               `json:"material"`
+	Color           string               `json:"color"`
+	MouldingWidthIn float64              `json:"mouldingWidthIn"`
+	DisplayOrder    int                  `json:"displayOrder"`
+	Images          []FrameImageResponse `json:"images"`
+	InStock         *bool                `json:"inStock,omitempty"` // catalogue listings only, from stock counts
+	ArchivedAt      *time.Time           `json:"archivedAt,omitempty"`
+}
+
+type FrameImageResponse struct {
+	ID       uuid.UUID `json:"id"`
+	URL      string    `json:"url"`
+	Alt      string    `json:"alt"`
+	Position int       `json:"position"`
+}
+
+var slugRe = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Slugify turns a name like "Oak Frame (Natural)" into "oak-frame-natural".
+func Slugify(name string) string {
+	return strings.Trim(slugRe.ReplaceAllString(strings.ToLower(name), "-"), "-")
+}
+
+// ReservedSlugs are path segments with their own routes next to
+// /v1/frames/:slug; a frame using one could never be fetched.
+var ReservedSlugs = []string{"size", "sizes", "archived"}
+
+// ValidSlug reports whether s is already in slug form and not reserved.
+func ValidSlug(s string) bool {
+	return s != "" && len(s) <= 100 && Slugify(s) == s && !slices.Contains(ReservedSlugs, s)
+}
+
+// DefaultSlug derives a frame's slug from its name, moving it off a reserved
+// segment: a frame named "Size" gets "size-frame".
+func DefaultSlug(name string) string {
+	s := Slugify(name)
+	if slices.Contains(ReservedSlugs, s) {
+		s += "-frame"
+	}
+	return s
+}
+
+var sizeNameRe = regexp.MustCompile(`(\d+(?:\.\d+)?)\s*[xX×]\s*(\d+(?:\.\d+)?)\s*(in|inch|inches|cm|mm)?\b`)
+
+// ParseSizeName reads the dimensions out of a size name such as "16x20 in" or
+// "A4 (8x12 in)". Names without a unit are taken to be in inches.
+func ParseSizeName(name string) (width, height float64, unit string, ok bool) {
+	m := sizeNameRe.FindStringSubmatch(name)
+	if m == nil {
+		return 0, 0, "", false
+	}
+	width, _ = strconv.ParseFloat(m[1], 64)
+	height, _ = strconv.ParseFloat(m[2], 64)
+	unit = UnitInch
+	if m[3] == UnitCM || m[3] == UnitMM {
+		unit = m[3]
+	}
+	return width, height, unit, width > 0 && height > 0
+}
+
+// ValidUnit reports whether u is a supported size unit.
+func ValidUnit(u string) bool {
+	return u == UnitInch || u == UnitCM || u == UnitMM
+}
+
+// OrientationOf names the shape of a width x height rectangle.
+func OrientationOf(width, height float64) string {
+	switch {
+	case width < height:
+		return Portrait
+	case width > height:
+		return Landscape
+	}
+	return Square
+}
+
+// Dimensions returns the physical print size in inches. Sizes created before
+// dimensions were stored fall back to parsing Name.
+func (s FrameSize) Dimensions() (width, height float64, ok bool) {
+	width, height, unit := s.Width, s.Height, s.Unit
+	if width <= 0 || height <= 0 {
+		if width, height, unit, ok = ParseSizeName(s.Name); !ok {
+			return 0, 0, false
+		}
+	}
+	switch unit {
+	case UnitCM:
+		width, height = width/2.54, height/2.54
+	case UnitMM:
+		width, height = width/25.4, height/25.4
+	}
+	return width, height, true
+}
+
+// AspectRatio is long side over short side, so a size matches a photo
+// whichever way round it hangs.
+func (s FrameSize) AspectRatio() (float64, bool) {
+	w, h, ok := s.Dimensions()
+	if !ok {
+		return 0, false
+	}
+	return math.Max(w, h) / math.Min(w, h), true
+}
+
+// FrameSizeResponse is a size as shown in the catalogue, in both unit systems.
+type FrameSizeResponse struct {
+	FrameSize
+	WidthIn     float64    `json:"widthIn"`
+	HeightIn    float64    `json:"heightIn"`
+	WidthCm     float64    `json:"widthCm"`
+	HeightCm    float64    `json:"heightCm"`
+	AreaSqIn    float64    `json:"areaSqIn"`
+	AspectRatio float64    `json:"aspectRatio"`
+	InStock     *bool      `json:"inStock,omitempty"` // catalogue listings only, from stock counts
+	ArchivedAt  *time.Time `json:"archivedAt,omitempty"`
+}
+
+func (s FrameSize) Response() FrameSizeResponse {
+	r := FrameSizeResponse{FrameSize: s, ArchivedAt: ArchivedAt(s.DeletedAt)}
+	if w, h, ok := s.Dimensions(); ok {
+		round := func(v float64) float64 { return math.Round(v*10) / 10 }
+		r.WidthIn, r.HeightIn = round(w), round(h)
+		r.WidthCm, r.HeightCm = round(w*2.54), round(h*2.54)
+		r.AreaSqIn = round(w * h)
+		r.AspectRatio, _ = s.AspectRatio()
+		r.AspectRatio = math.Round(r.AspectRatio*1000) / 1000
+	}
+	return r
+}
+
+// SetDimensions fills Width, Height, Unit and Orientation.
+func (s *FrameSize) SetDimensions(width, height float64, unit string) {
+	s.Width, s.Height, s.Unit = width, height, unit
+	s.Orientation = OrientationOf(width, height)
+}
+
+// FrameTemplate holds what the mockup renderer needs to draw a Frame.
+type FrameTemplate struct {
+	FrameID      uuid.UUID `gorm:"type:uuid;primaryKey" json:"frameId"`
+	BorderColor  string    `gorm:"size:7;default:'#3b2a1a'" json:"borderColor"` // used when there is no texture
+	TextureKey   string    `gorm:"size:300" json:"textureKey,omitempty"`        // tileable moulding image in storage
+	FrameWidthIn float64   `gorm:"default:1" json:"frameWidthIn"`               // moulding width shown in mockups
+	UpdatedAt    time.Time `json:"updatedAt"`
 }
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// StockItem counts the mouldings on hand for one frame and size. Combinations
+// without a row aren't tracked and never run out.
+type StockItem struct {
+	ID                 uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	FrameID            uuid.UUID  `gorm:"type:uuid;uniqueIndex:idx_stock_frame_size" json:"frameId"`
+	Frame              Frame      `gorm:"foreignKey:FrameID;constraint:OnDelete:CASCADE" json:"-"`
+	SizeID             uuid.UUID  `gorm:"type:uuid;uniqueIndex:idx_stock_frame_size" json:"sizeId"`
+	Size               FrameSize  `gorm:"foreignKey:SizeID;constraint:OnDelete:CASCADE" json:"-"`
+	OnHand             int        `gorm:"not null;default:0" json:"onHand"`
+	Reserved           int        `gorm:"not null;default:0" json:"reserved"` // held for paid orders not yet in production
+	LowStockThreshold  int        `gorm:"not null;default:0" json:"lowStockThreshold"`
+	LowStockNotifiedAt *time.Time `json:"lowStockNotifiedAt"` // cleared once restocked above the threshold
+	CreatedAt          time.Time  `json:"createdAt"`
+	UpdatedAt          time.Time  `json:"updatedAt"`
+}
+
+// Available is what new orders can still take.
+func (s *StockItem) Available() int {
+	return s.OnHand - s.Reserved
+}
+
+// Low reports whether the item is at or below its threshold.
+func (s *StockItem) Low() bool {
+	return s.LowStockThreshold > 0 && s.Available() <= s.LowStockThreshold
+}
+
+// Order stock states, so each order reserves and consumes stock once
+const (
+	StockReserved = "reserved"
+	StockConsumed = "consumed"
+	StockReleased = "released"
+)
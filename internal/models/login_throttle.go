@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// LoginThrottle tracks failed sign-in attempts for one key, either an
+// account ("acct:<email>") or a client IP ("ip:<addr>").
+type LoginThrottle struct {
+	Key           string     `gorm:"primaryKey;size:320" json:"key"`
+	Failures      int        `gorm:"not null;default:0" json:"failures"`
+	LastFailureAt time.Time  `json:"lastFailureAt"`
+	LockedUntil   *time.Time `gorm:"index" json:"lockedUntil"`
+	UpdatedAt     time.Time  `json:"updatedAt"`
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Multipart upload statuses
+const (
+	MultipartActive    = "active"
+	MultipartCompleted = "completed"
+	MultipartAborted   = "aborted"
+)
+
+// MultipartUpload records a resumable upload of a large image, so the client
+// can pick it up again after a disconnect. Uploaded parts live in storage.
+type MultipartUpload struct {
+	ID          uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	UserID      uuid.UUID `gorm:"type:uuid;index" json:"userId"`
+	AssetID     uuid.UUID `gorm:"type:uuid;index" json:"assetId"`
+	ObjectKey   string    `gorm:"size:300" json:"key"`
+	UploadID    string    `gorm:"size:300" json:"-"` // storage's multipart upload ID
+	ContentType string    `gorm:"size:60" json:"contentType"`
+	Size        int64     `json:"size"`     // declared total size
+	PartSize    int64     `json:"partSize"` // every part but the last
+	PartCount   int       `json:"partCount"`
+	Status      string    `gorm:"size:20;index" json:"status"`
+	ExpiresAt   time.Time `gorm:"index" json:"expiresAt"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
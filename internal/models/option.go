@@ -0,0 +1,97 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OptionGroup is an add-on customers pick alongside frame and size, e.g. mat
+// colour, glass type or hanging hardware.
+type OptionGroup struct {
+	ID           uuid.UUID      `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	Name         string         `gorm:"size:80;uniqueIndex;not null" json:"name"`
+	Description  string         `gorm:"size:400" json:"description"`
+	Required     bool           `gorm:"not null;default:false" json:"required"`    // orders must pick a choice
+	MultiSelect  bool           `gorm:"not null;default:false" json:"multiSelect"` // more than one choice allowed
+	DisplayOrder int            `gorm:"default:0;index" json:"displayOrder"`
+	FrameIDs     string         `gorm:"size:2000" json:"-"` // comma separated, empty for every frame
+	SizeIDs      string         `gorm:"size:2000" json:"-"` // comma separated, empty for every size
+	Choices      []OptionChoice `gorm:"foreignKey:GroupID;constraint:OnDelete:CASCADE" json:"choices"`
+	CreatedAt    time.Time      `json:"createdAt"`
+	UpdatedAt    time.Time      `json:"updatedAt"`
+}
+
+// OptionChoice is one pick within a group; PriceDelta is added to the order total.
+type OptionChoice struct {
+	ID           uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	GroupID      uuid.UUID `gorm:"type:uuid;index" json:"groupId"`
+	Name         string    `gorm:"size:80;not null" json:"name"`
+	PriceDelta   int       `gorm:"not null;default:0" json:"priceDelta"`
+	IsDefault    bool      `gorm:"not null;default:false" json:"default"` // picked when a required group is left out
+	Status       string    `gorm:"size:40;default:'available'" json:"status"`
+	DisplayOrder int       `gorm:"default:0" json:"displayOrder"`
+	CreatedAt    time.Time `json:"createdAt"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
+// OrderOption records a chosen add-on on an order. Names and price are copied
+// so later catalogue edits don't change what was ordered.
+type OrderOption struct {
+	ID         uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"-"`
+	OrderID    uuid.UUID `gorm:"type:uuid;index" json:"-"`
+	GroupID    uuid.UUID `gorm:"type:uuid" json:"groupId"`
+	ChoiceID   uuid.UUID `gorm:"type:uuid" json:"choiceId"`
+	GroupName  string    `gorm:"size:80" json:"group"`
+	ChoiceName string    `gorm:"size:80" json:"choice"`
+	PriceDelta int       `json:"priceDelta"`
+}
+
+// OptionGroupResponse adds the frame and size restrictions as lists.
+type OptionGroupResponse struct {
+	OptionGroup
+	FrameIDs []string `json:"frameIds"`
+	SizeIDs  []string `json:"sizeIds"`
+}
+
+func (g *OptionGroup) Response() OptionGroupResponse {
+	return OptionGroupResponse{OptionGroup: *g, FrameIDs: splitIDs(g.FrameIDs), SizeIDs: splitIDs(g.SizeIDs)}
+}
+
+// AppliesTo reports whether the group is offered for a frame and size.
+func (g *OptionGroup) AppliesTo(frameID, sizeID uuid.UUID) bool {
+	return g.AppliesToFrame(frameID) && g.AppliesToSize(sizeID)
+}
+
+func (g *OptionGroup) AppliesToFrame(id uuid.UUID) bool { return listAllows(g.FrameIDs, id) }
+
+func (g *OptionGroup) AppliesToSize(id uuid.UUID) bool { return listAllows(g.SizeIDs, id) }
+
+func listAllows(list string, id uuid.UUID) bool {
+	if list == "" {
+		return true
+	}
+	for _, s := range strings.Split(list, ",") {
+		if s == id.String() {
+			return true
+		}
+	}
+	return false
+}
+
+func splitIDs(list string) []string {
+	if list == "" {
+		return []string{}
+	}
+	return strings.Split(list, ",")
+}
+
+// JoinIDs is the stored form of a frame or size restriction.
+func JoinIDs(ids []uuid.UUID) string {
+	s := make([]string, len(ids))
+	for i, id := range ids {
+		s[i] = id.String()
+	}
+	return strings.Join(s, ",")
+}
@@ -1,25 +1,58 @@
 package models
 
 import (
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// Order statuses. Paid reserves stock, In Production consumes it and
+// Cancelled gives a reservation back.
+const (
+	OrderPending      = "Pending"
+	OrderPaid         = "Paid"
+	OrderInProduction = "In Production"
+	OrderShipped      = "Shipped"
+	OrderCancelled    = "Cancelled"
+	OrderDelivered    = "Delivered" // final; images are subject to the retention period
+)
+
+// NormalizeOrderStatus returns the canonical spelling of a known order
+// status, matched case-insensitively, and any other status unchanged.
+func NormalizeOrderStatus(s string) string {
+	for _, st := range []string{OrderPending, OrderPaid, OrderInProduction, OrderShipped, OrderCancelled, OrderDelivered} {
+		if strings.EqualFold(s, st) {
+			return st
+		}
+	}
+	return s
+}
+
 type Order struct {
-	ID        uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4()" json:"id"`
-	OrderID   string    `gorm:"uniqueIndex;size:40" json:"orderId"`
-	UserID    uuid.UUID `gorm:"type:uuid" json:"userId"`
-	User      User      `gorm:"foreignKey:UserID"`
-	FrameID   uuid.UUID `gorm:"type:uuid" json:"frameId"`
-	Frame     Frame     `gorm:"foreignKey:FrameID"`
-	SizeID    uuid.UUID `gorm:"type:uuid" json:"sizeId"`
-	Size      FrameSize `gorm:"foreignKey:SizeID"`
-	ImageURL  string    `gorm:"size:600" json:"imageUrl"`
-	Status    string    `gorm:"size:40;default:'Pending'" json:"status"`
-	Notes     string    `gorm:"size:400" json:"notes"`
-	CreatedAt time.Time
-	UpdatedAt time.Time
+	ID           uuid.UUID     `gorm:"type:uuid;default:uuid_generate_v4()" json:"id"`
+	OrderID      string        `gorm:"uniqueIndex;size:40" json:"orderId"`
+	UserID       uuid.UUID     `gorm:"type:uuid" json:"userId"`
+	User         User          `gorm:"foreignKey:UserID"`
+	FrameID      uuid.UUID     `gorm:"type:uuid" json:"frameId"`
+	Frame        Frame         `gorm:"foreignKey:FrameID"`
+	SizeID       uuid.UUID     `gorm:"type:uuid" json:"sizeId"`
+	Size         FrameSize     `gorm:"foreignKey:SizeID"`
+	AssetID      *uuid.UUID    `gorm:"type:uuid;index" json:"assetId"` // nil for orders placed before uploads were tracked
+	Asset        *Asset        `gorm:"foreignKey:AssetID" json:"-"`
+	ImageURL     string        `gorm:"size:600" json:"imageUrl"` // plain URL on legacy orders only; responses mint a presigned one
+	Placement    Placement     `gorm:"embedded;embeddedPrefix:placement_" json:"placement"`
+	PrintFileKey string        `gorm:"size:300" json:"printFileKey,omitempty"` // print-ready render, once made
+	Options      []OrderOption `gorm:"foreignKey:OrderID;constraint:OnDelete:CASCADE" json:"options"`
+	Total        int           `json:"total"`                       // size price plus option deltas at order time
+	PrintDPI     int           `json:"printDpi"`                    // effective DPI at order time, 0 if unknown
+	PrintQuality string        `gorm:"size:20" json:"printQuality"` // ok / warning at order time
+	Status       string        `gorm:"size:40;default:'Pending'" json:"status"`
+	StockState   string        `gorm:"size:12" json:"stockState,omitempty"` // reserved, consumed or released; empty if untracked
+	Notes        string        `gorm:"size:400" json:"notes"`
+	DeliveredAt  *time.Time    `gorm:"index" json:"deliveredAt,omitempty"` // start of the retention period
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
 }
 
 type OrderResponse struct {
@@ -40,10 +73,61 @@ type OrderResponse struct {
 	} `json:"size"`
 	// Frame     string    `json:"frame"`
 	// Size      string    `json:"size"`
-	Price     int       `json:"price"`
-	ImageURL  string    `json:"imageUrl"`
-	Status    string    `json:"status"`
-	Notes     string    `json:"notes"`
-	CreatedAt time.Time `json:"createdAt"`
-	UpdatedAt time.Time `json:"updatedAt"`
+	Price      int           `json:"price"`
+	AssetID    *uuid.UUID    `json:"assetId"`
+	ImageURL   string        `json:"imageUrl"`
+	Thumbnails *Thumbnails   `json:"thumbnails,omitempty"` // nil until the worker has made them
+	Placement  Placement     `json:"placement"`
+	Options    []OrderOption `json:"options"`
+	Total      int           `json:"total"`
+	Status     string        `json:"status"`
+	Notes      string        `json:"notes"`
+	CreatedAt  time.Time     `json:"createdAt"`
+	UpdatedAt  time.Time     `json:"updatedAt"`
+}
+
+// Amount is what the order costs. Orders placed before totals were stored
+// fall back to the size price.
+func (o *Order) Amount() int {
+	if o.Total > 0 {
+		return o.Total
+	}
+	return o.Size.Price
+}
+
+// SizePrice is what the size cost when the order was placed: the stored total
+// less the option deltas. Needs Options loaded.
+func (o *Order) SizePrice() int {
+	if o.Total <= 0 {
+		return o.Size.Price
+	}
+	price := o.Total
+	for _, opt := range o.Options {
+		price -= opt.PriceDelta
+	}
+	return price
+}
+
+// Thumbnails are the resized copies of an order's image
+type Thumbnails struct {
+	Thumb   string `json:"thumb"`
+	Medium  string `json:"medium"`
+	Preview string `json:"preview"`
+}
+
+// Fit modes
+const (
+	FitFill = "fill" // crop fills the print edge to edge
+	FitFit  = "fit"  // whole crop is shown, padded with a white border
+)
+
+// Placement tells the workshop how to put the image on the print. The crop is
+// in pixels of the upright image after Rotation has been applied.
+type Placement struct {
+	CropX    int    `json:"cropX"`
+	CropY    int    `json:"cropY"`
+	CropW    int    `json:"cropW"`
+	CropH    int    `json:"cropH"`
+	Rotation int    `json:"rotation"` // clockwise degrees: 0, 90, 180 or 270
+	FitMode  string `gorm:"size:10" json:"fitMode"`
 }
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PriceVersion is a frame size price from EffectiveFrom on. Future versions
+// are scheduled; the pricing scheduler copies them onto FrameSize.Price once
+// due and stamps ActivatedAt.
+type PriceVersion struct {
+	ID            uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	SizeID        uuid.UUID  `gorm:"type:uuid;index:idx_price_size_from" json:"sizeId"`
+	Price         int        `gorm:"not null" json:"price"`
+	EffectiveFrom time.Time  `gorm:"index:idx_price_size_from" json:"effectiveFrom"`
+	ActivatedAt   *time.Time `gorm:"index" json:"activatedAt"` // nil while scheduled
+	Note          string     `gorm:"size:200" json:"note"`
+	CreatedBy     *uuid.UUID `gorm:"type:uuid" json:"createdBy,omitempty"` // nil for seeded and backfilled prices
+	CreatedAt     time.Time  `json:"createdAt"`
+}
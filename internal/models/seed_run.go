@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// SeedRun records a catalogue seed file version that has been applied, so
+// each version runs once unless forced.
+type SeedRun struct {
+	Version   int       `gorm:"primaryKey;autoIncrement:false" json:"version"`
+	File      string    `gorm:"size:300" json:"file"`
+	Checksum  string    `gorm:"size:64" json:"checksum"` // sha256 of the file
+	Forced    bool      `json:"forced"`
+	Created   int       `json:"created"`
+	Updated   int       `json:"updated"`
+	AppliedAt time.Time `json:"appliedAt"`
+}
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"math"
+	"time"
+)
+
+// PrintQualitySettings holds the admin-tunable DPI thresholds. There is a
+// single row with ID 1.
+type PrintQualitySettings struct {
+	ID        uint      `gorm:"primaryKey" json:"-"`
+	WarnDPI   int       `gorm:"not null;default:150" json:"warnDpi"` // below this: warn the customer
+	MinDPI    int       `gorm:"not null;default:100" json:"minDpi"`  // below this: refuse the order
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// CustomSizePricing is the admin-tunable formula for sizes outside the
+// catalogue: a base charge, plus the glazed area, plus moulding for the
+// perimeter, never less than MinPrice. There is a single row with ID 1.
+type CustomSizePricing struct {
+	ID               uint      `gorm:"primaryKey" json:"-"`
+	BasePrice        int       `gorm:"not null;default:3000" json:"basePrice"`
+	PerSqInch        float64   `gorm:"not null;default:12" json:"perSqInch"`
+	PerPerimeterInch float64   `gorm:"not null;default:60" json:"perPerimeterInch"`
+	MinPrice         int       `gorm:"not null;default:6000" json:"minPrice"`
+	MinDimensionIn   float64   `gorm:"not null;default:4" json:"minDimensionIn"`
+	MaxDimensionIn   float64   `gorm:"not null;default:60" json:"maxDimensionIn"`
+	QuoteTTLHours    int       `gorm:"not null;default:72" json:"quoteTtlHours"`
+	UpdatedAt        time.Time `json:"updatedAt"`
+}
+
+// CustomSizePrice is a priced custom size with the workings shown.
+type CustomSizePrice struct {
+	AreaSqIn     float64 `json:"areaSqIn"`
+	PerimeterIn  float64 `json:"perimeterIn"`
+	BasePrice    int     `json:"basePrice"`
+	AreaCost     int     `json:"areaCost"`
+	MouldingCost int     `json:"mouldingCost"`
+	Total        int     `json:"total"` // after the minimum
+}
+
+// Price applies the formula to a print of widthIn x heightIn inches.
+func (p *CustomSizePricing) Price(widthIn, heightIn float64) CustomSizePrice {
+	r := CustomSizePrice{
+		AreaSqIn:    math.Round(widthIn*heightIn*10) / 10,
+		PerimeterIn: math.Round(2*(widthIn+heightIn)*10) / 10,
+		BasePrice:   p.BasePrice,
+	}
+	r.AreaCost = int(math.Round(widthIn * heightIn * p.PerSqInch))
+	r.MouldingCost = int(math.Round(2 * (widthIn + heightIn) * p.PerPerimeterInch))
+	r.Total = max(r.BasePrice+r.AreaCost+r.MouldingCost, p.MinPrice)
+	return r
+}
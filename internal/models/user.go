@@ -16,6 +16,16 @@ type User struct {
     Address   string    `gorm:"size:400"`
 	IsAdmin   bool      `gorm:"default:false"`
 	IsActive  bool      `gorm:"default:true"`
+
+	// EmailVerified is set once someone proved they own Email, e.g. through a
+	// provider's verified ID token. Password sign-up doesn't set it.
+	EmailVerified bool `gorm:"not null;default:false" json:"-"`
+
+	// Two-factor authentication (TOTP)
+	TOTPSecret   string `gorm:"size:64" json:"-"` // set on enroll, active once TOTPEnabled
+	TOTPEnabled  bool   `gorm:"default:false"`
+	TOTPLastStep int64  `json:"-"` // last accepted time step, blocks code replay
+
 	CreatedAt time.Time
 	UpdatedAt time.Time
 }
@@ -27,3 +37,12 @@ func (u *User) BeforeCreate(tx *gorm.DB) (err error) {
 	}
 	return
 }
+
+// RecoveryCode is a single-use 2FA backup code, stored hashed.
+type RecoveryCode struct {
+	ID        uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	UserID    uuid.UUID  `gorm:"type:uuid;index" json:"userId"`
+	CodeHash  string     `gorm:"size:64;uniqueIndex" json:"-"`
+	UsedAt    *time.Time `json:"usedAt"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
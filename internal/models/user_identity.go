@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UserIdentity links a User to an external OpenID Connect account.
+type UserIdentity struct {
+	ID        uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	UserID    uuid.UUID `gorm:"type:uuid;index" json:"userId"`
+	Provider  string    `gorm:"size:40;uniqueIndex:idx_identity_provider_subject" json:"provider"` // e.g. "google", "apple"
+	Subject   string    `gorm:"size:255;uniqueIndex:idx_identity_provider_subject" json:"subject"`
+	Email     string    `gorm:"size:255" json:"email"`
+	CreatedAt time.Time `json:"createdAt"`
+}
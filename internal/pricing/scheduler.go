@@ -0,0 +1,99 @@
+// Package pricing keeps frame size prices versioned: every change is a
+// PriceVersion, and future ones are activated by the Scheduler when due.
+package pricing
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/olamideolayemi/framelane-api/internal/models"
+)
+
+// checkEvery is how often the scheduler looks for versions that became due.
+const checkEvery = time.Minute
+
+// Record stores a price that takes effect immediately, e.g. from an admin
+// edit, so the history stays complete.
+func Record(tx *gorm.DB, sizeID uuid.UUID, price int, note string, by *uuid.UUID) error {
+	now := time.Now()
+	return tx.Create(&models.PriceVersion{
+		ID:            uuid.New(),
+		SizeID:        sizeID,
+		Price:         price,
+		EffectiveFrom: now,
+		ActivatedAt:   &now,
+		Note:          note,
+		CreatedBy:     by,
+	}).Error
+}
+
+// At returns the size's price at t: the latest version effective by then.
+// ok is false before the first recorded version.
+func At(db *gorm.DB, sizeID uuid.UUID, t time.Time) (price int, ok bool, err error) {
+	var v models.PriceVersion
+	err = db.Where("size_id = ? AND effective_from <= ?", sizeID, t).
+		Order("effective_from DESC, created_at DESC").First(&v).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return v.Price, true, nil
+}
+
+// Scheduler activates scheduled price versions once their time comes.
+type Scheduler struct {
+	DB *gorm.DB
+}
+
+// Run activates due versions now and then every checkEvery until ctx is done.
+func (s *Scheduler) Run(ctx context.Context) {
+	t := time.NewTicker(checkEvery)
+	defer t.Stop()
+	for {
+		if n, err := s.Activate(time.Now()); err != nil {
+			log.Printf("pricing: %v", err)
+		} else if n > 0 {
+			log.Printf("pricing: activated %d scheduled price(s)", n)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// Activate applies every version due by now, oldest first, so when several
+// came due for a size the latest one wins.
+func (s *Scheduler) Activate(now time.Time) (int, error) {
+	var due []models.PriceVersion
+	if err := s.DB.Where("activated_at IS NULL AND effective_from <= ?", now).
+		Order("effective_from ASC, created_at ASC").Find(&due).Error; err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, v := range due {
+		err := s.DB.Transaction(func(tx *gorm.DB) error {
+			res := tx.Model(&models.PriceVersion{}).
+				Where("id = ? AND activated_at IS NULL", v.ID).
+				Update("activated_at", now)
+			if res.Error != nil || res.RowsAffected == 0 {
+				return res.Error // already activated by another instance
+			}
+			// archived sizes too, so a restored size has the current price
+			return tx.Unscoped().Model(&models.FrameSize{}).Where("id = ?", v.SizeID).Update("price", v.Price).Error
+		})
+		if err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
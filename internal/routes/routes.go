@@ -5,75 +5,202 @@ import (
 	"gorm.io/gorm"
 
 	"github.com/olamideolayemi/framelane-api/internal/auth"
+	"github.com/olamideolayemi/framelane-api/internal/cleanup"
 	"github.com/olamideolayemi/framelane-api/internal/email"
 	"github.com/olamideolayemi/framelane-api/internal/handlers"
+	"github.com/olamideolayemi/framelane-api/internal/inventory"
+	"github.com/olamideolayemi/framelane-api/internal/models"
+	"github.com/olamideolayemi/framelane-api/internal/scan"
 	"github.com/olamideolayemi/framelane-api/internal/storage"
+	"github.com/olamideolayemi/framelane-api/internal/thumbs"
 )
 
 type Deps struct {
-	DB        *gorm.DB
-	JWTSecret string
-	JWTHours  int
-	S3        *storage.S3
-	Email     *email.Sender
+	DB                *gorm.DB
+	Keys              *auth.KeySet
+	JWTHours          int
+	TOTPIssuer        string
+	OIDC              map[string]*auth.OIDCProvider
+	Throttle          *auth.Throttle
+	APIKeys           *auth.APIKeys
+	Store             storage.Store
+	LocalStore        *storage.Local // set when objects are kept on disk
+	UploadMaxBytes    int64
+	MultipartMaxBytes int64
+	ImageMaxPixels    int // decode limit, see imaging.Decode
+	Scanner           scan.Scanner
+	Thumbs            *thumbs.Worker
+	Cleanup           *cleanup.Collector
+	Email             *email.Sender
+	Inventory         *inventory.Service
 }
 
 func Setup(r *gin.Engine, d Deps) {
 	r.GET("/v1/health", handlers.Health)
-	fh := &handlers.FrameHandler{DB: d.DB}
+	kh := &handlers.KeysHandler{Keys: d.Keys}
+	r.GET("/.well-known/jwks.json", kh.JWKS)
+	fh := &handlers.FrameHandler{DB: d.DB, Store: d.Store, Inventory: d.Inventory}
 
-	ah := &handlers.AuthHandler{DB: d.DB, JWTSecret: d.JWTSecret, JWTHours: d.JWTHours}
+	ah := &handlers.AuthHandler{DB: d.DB, Keys: d.Keys, JWTHours: d.JWTHours, Throttle: d.Throttle, Email: d.Email, OIDC: d.OIDC}
 	r.POST("/v1/auth/register", ah.Register)
 	r.POST("/v1/auth/login", ah.Login)
+	r.POST("/v1/auth/login/2fa", ah.LoginTwoFactor)
+	r.POST("/v1/auth/oidc/:provider", ah.OIDCLogin)
+	tfh := &handlers.TwoFactorHandler{DB: d.DB, Issuer: d.TOTPIssuer}
 
-	uh := &handlers.UploadHandler{S3: d.S3}
-	r.GET("/v1/upload-url", auth.RequireAuth(d.JWTSecret), uh.GetPresignedURL)
+	uh := &handlers.UploadHandler{DB: d.DB, Store: d.Store, MaxBytes: d.UploadMaxBytes}
+	r.GET("/v1/upload-url", auth.RequireAuth(d.Keys), uh.GetPresignedURL)
 
-	oh := &handlers.OrdersHandler{DB: d.DB, Email: d.Email}
+	// Signed URLs of the local storage backend point back here
+	if d.LocalStore != nil {
+		sh := &handlers.LocalStorageHandler{Store: d.LocalStore, MaxBytes: d.UploadMaxBytes}
+		r.GET(storage.LocalRoute+"/object", sh.Download)
+		r.PUT(storage.LocalRoute+"/object", sh.Upload)
+		r.POST(storage.LocalRoute+"/upload", sh.UploadForm)
+		r.PUT(storage.LocalRoute+"/part", sh.UploadPart)
+	}
+
+	oh := &handlers.OrdersHandler{DB: d.DB, Store: d.Store, Email: d.Email, Inventory: d.Inventory, MaxPixels: d.ImageMaxPixels}
 	r.GET("/v1/track/:orderId", oh.Track)
 
-	ph := &handlers.PaymentsHandler{DB: d.DB}
+	ph := &handlers.PaymentsHandler{DB: d.DB, Inventory: d.Inventory}
 	r.POST("/v1/payments/intent", ph.CreateIntent)
 	r.POST("/v1/payments/webhook", ph.Webhook)
 
 	// Public routes
 	r.GET("/v1/frames/size", fh.ListFrameSizes) // List all frame sizes
 	r.GET("/v1/frames", fh.ListFrameTypes)      // List all frames
+	r.GET("/v1/frames/:slug", fh.GetFrameType)
+
+	opth := &handlers.OptionsHandler{DB: d.DB}
+	r.GET("/v1/options", opth.List) // add-ons, filtered by frameId and sizeId
+
+	// orders: user session or partner API key
+	orders := r.Group("/v1/orders")
+	orders.Use(auth.RequireAuthOrAPIKey(d.Keys, d.APIKeys))
+	{
+		orders.GET("", auth.RequireScope(models.ScopeOrdersRead), oh.ListMine)
+		orders.POST("", auth.RequireScope(models.ScopeOrdersWrite), oh.Create)
+		orders.POST("/quote", auth.RequireScope(models.ScopeOrdersRead), oh.Quote)
+		orders.POST("/custom-size-quotes", auth.RequireScope(models.ScopeOrdersWrite), oh.QuoteCustomSize)
+		orders.GET("/custom-size-quotes/:id", auth.RequireScope(models.ScopeOrdersRead), oh.GetCustomSizeQuote)
+	}
 
 	// user
 	user := r.Group("/v1")
-	user.Use(auth.RequireAuth(d.JWTSecret))
+	user.Use(auth.RequireAuth(d.Keys))
 	{
-		user.GET("/orders", oh.ListMine)
-		user.POST("/orders", oh.Create)
-
 		uh := &handlers.UsersHandler{DB: d.DB}
 		user.PUT("/user/profile", uh.UpdateUserProfile)
+
+		// Uploaded images
+		uph := &handlers.UploadHandler{DB: d.DB, Store: d.Store, MaxBytes: d.UploadMaxBytes, Thumbs: d.Thumbs, MultipartMaxBytes: d.MultipartMaxBytes, Scanner: d.Scanner}
+		user.GET("/uploads/:id", uph.GetAsset)
+		user.POST("/uploads/:id/finalize", uph.Finalize)
+
+		// Resumable uploads for large files
+		user.POST("/uploads/multipart", uph.StartMultipart)
+		user.GET("/uploads/multipart/:id", uph.GetMultipart)
+		user.POST("/uploads/multipart/:id/parts", uph.PartURLs)
+		user.POST("/uploads/multipart/:id/complete", uph.CompleteMultipart)
+		user.DELETE("/uploads/multipart/:id", uph.AbortMultipart)
+
+		mh := &handlers.MockupHandler{DB: d.DB, Store: d.Store, MaxPixels: d.ImageMaxPixels}
+		user.GET("/mockups", mh.Render)
+
+		// Two-factor authentication
+		user.POST("/user/2fa/enroll", tfh.Enroll)
+		user.POST("/user/2fa/verify", tfh.Verify)
+		user.POST("/user/2fa/recovery-codes", tfh.RegenerateRecoveryCodes)
+		user.POST("/user/2fa/disable", tfh.Disable)
 	}
 
 	// admin
 	admin := r.Group("/v1/admin")
-	admin.Use(auth.RequireAuth(d.JWTSecret), auth.RequireAdmin())
+	admin.Use(auth.RequireAuth(d.Keys), auth.RequireAdmin())
 	{
 		admin.GET("/orders", oh.ListAll)
 		admin.PATCH("/orders/:id/status", oh.UpdateStatus)
 		admin.DELETE("/orders/:id", oh.DeleteOrder)
+		admin.POST("/orders/:id/print-file", oh.RenderPrintFile)
+		admin.GET("/orders/:id/original", oh.DownloadOriginal)
+
+		// Storage cleanup
+		ch := &handlers.CleanupHandler{GC: d.Cleanup}
+		admin.GET("/storage/cleanup", ch.Last)
+		admin.POST("/storage/cleanup", ch.Run)
+
+		// Quarantined uploads
+		qh := &handlers.UploadHandler{DB: d.DB, Store: d.Store}
+		admin.GET("/quarantine", qh.ListQuarantined)
+		admin.GET("/quarantine/:id/download", qh.DownloadQuarantined)
+		admin.DELETE("/quarantine/:id", qh.DeleteQuarantined)
 
 		// User management
-		uh := &handlers.UsersHandler{DB: d.DB}
+		uh := &handlers.UsersHandler{DB: d.DB, Throttle: d.Throttle}
 		admin.GET("/users", uh.ListUsers)
+		admin.GET("/users/locked", uh.ListLockedUsers)
 		admin.GET("/users/:id", uh.GetUser)
 		admin.PATCH("/users/:id/suspend", uh.SuspendUser)
 		admin.DELETE("/users/:id", uh.DeleteUser)
+		admin.DELETE("/users/:id/2fa", tfh.AdminReset)
+		admin.DELETE("/users/:id/lock", uh.UnlockUser)
+
+		// Partner API keys
+		akh := &handlers.APIKeysHandler{DB: d.DB}
+		admin.GET("/api-keys", akh.List)
+		admin.POST("/api-keys", akh.Create)
+		admin.PATCH("/api-keys/:id", akh.Update)
+		admin.POST("/api-keys/:id/rotate", akh.Rotate)
+		admin.DELETE("/api-keys/:id", akh.Revoke)
+
+		// Settings
+		sh := &handlers.SettingsHandler{DB: d.DB}
+		admin.GET("/settings/print-quality", sh.GetPrintQuality)
+		admin.PUT("/settings/print-quality", sh.UpdatePrintQuality)
+		admin.GET("/settings/custom-size-pricing", sh.GetCustomSizePricing)
+		admin.PUT("/settings/custom-size-pricing", sh.UpdateCustomSizePricing)
 
 		// Frame sizes
 		admin.POST("/frames/size", fh.CreateFrameSize)
 		admin.PUT("/frames/size/:id", fh.UpdateFrameSize)
-		admin.DELETE("/frames/size/:id", fh.DeleteFrameSize)
+		admin.DELETE("/frames/size/:id", fh.DeleteFrameSize) // archives
+		admin.GET("/frames/size/archived", fh.ListArchivedSizes)
+		admin.POST("/frames/size/:id/restore", fh.RestoreFrameSize)
+		admin.GET("/frames/size/:id/prices", fh.ListPrices)
+		admin.POST("/frames/size/:id/prices", fh.SchedulePrice)
+		admin.DELETE("/frames/size/:id/prices/:versionId", fh.CancelPrice)
+		admin.GET("/prices/preview", fh.PreviewPrices)
+
+		// Spreadsheet import and export
+		cath := &handlers.CatalogHandler{DB: d.DB}
+		admin.GET("/catalog/export", cath.Export)
+		admin.POST("/catalog/import", cath.Import)
 
 		// Frame types
 		admin.POST("/frames", fh.CreateFrameType)
 		admin.PUT("/frames/:id", fh.UpdateFrameType)
-		admin.DELETE("/frames/:id", fh.DeleteFrameType)
+		admin.DELETE("/frames/:id", fh.DeleteFrameType) // archives
+		admin.GET("/frames/archived", fh.ListArchivedFrames)
+		admin.POST("/frames/:id/restore", fh.RestoreFrameType)
+		admin.GET("/frames/:id/prices", fh.ListFramePrices)
+		admin.POST("/frames/:id/images", fh.AddFrameImage)
+		admin.PATCH("/frames/:id/images/:imageId", fh.UpdateFrameImage)
+		admin.DELETE("/frames/:id/images/:imageId", fh.DeleteFrameImage)
+		admin.PUT("/frames/:id/template", (&handlers.MockupHandler{DB: d.DB, Store: d.Store, MaxPixels: d.ImageMaxPixels}).UpdateTemplate)
+
+		admin.GET("/options", opth.AdminList)
+		admin.POST("/options", opth.CreateGroup)
+		admin.PUT("/options/:id", opth.UpdateGroup)
+		admin.DELETE("/options/:id", opth.DeleteGroup)
+		admin.POST("/options/:id/choices", opth.AddChoice)
+		admin.PUT("/options/:id/choices/:choiceId", opth.UpdateChoice)
+		admin.DELETE("/options/:id/choices/:choiceId", opth.DeleteChoice)
+
+		ih := &handlers.InventoryHandler{DB: d.DB, Inventory: d.Inventory}
+		admin.GET("/inventory", ih.List)
+		admin.PUT("/inventory", ih.Set)
+		admin.POST("/inventory/:id/adjust", ih.Adjust)
+		admin.DELETE("/inventory/:id", ih.Delete)
 	}
 }
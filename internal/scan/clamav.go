@@ -0,0 +1,95 @@
+package scan
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// ClamAV scans through a clamd daemon using the INSTREAM command over TCP.
+type ClamAV struct {
+	Addr      string        // host:port of clamd, usually :3310
+	Timeout   time.Duration // whole scan, including sending the file
+	ChunkSize int
+}
+
+const defaultChunkSize = 64 << 10
+
+func (s *ClamAV) Scan(ctx context.Context, r io.Reader) (Result, error) {
+	timeout := s.Timeout
+	if timeout <= 0 {
+		timeout = time.Minute
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", s.Addr)
+	if err != nil {
+		return Result{}, fmt.Errorf("clamd: %w", err)
+	}
+	defer conn.Close()
+	if dl, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(dl)
+	}
+
+	if _, err := conn.Write([]byte("zINSTREAM\x00")); err != nil {
+		return Result{}, fmt.Errorf("clamd: %w", err)
+	}
+
+	// Each chunk is prefixed with its length; a zero length ends the stream
+	size := s.ChunkSize
+	if size <= 0 {
+		size = defaultChunkSize
+	}
+	buf := make([]byte, 4+size)
+	for {
+		n, rerr := io.ReadFull(r, buf[4:])
+		if n > 0 {
+			binary.BigEndian.PutUint32(buf[:4], uint32(n))
+			if _, err := conn.Write(buf[:4+n]); err != nil {
+				// clamd hangs up once the stream exceeds its StreamMaxLength
+				return s.reply(conn, err)
+			}
+		}
+		if rerr == io.EOF || rerr == io.ErrUnexpectedEOF {
+			break
+		}
+		if rerr != nil {
+			return Result{}, rerr
+		}
+	}
+	if _, err := conn.Write([]byte{0, 0, 0, 0}); err != nil {
+		return s.reply(conn, err)
+	}
+	return s.reply(conn, nil)
+}
+
+// reply reads clamd's answer, e.g. "stream: OK" or "stream: Eicar-Signature FOUND".
+func (s *ClamAV) reply(conn net.Conn, writeErr error) (Result, error) {
+	line, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && line == "" {
+		if writeErr != nil {
+			return Result{}, fmt.Errorf("clamd: %w", writeErr)
+		}
+		return Result{}, fmt.Errorf("clamd: %w", err)
+	}
+	line = strings.TrimSpace(strings.TrimRight(line, "\x00"))
+	msg := strings.TrimPrefix(line, "stream: ")
+
+	switch {
+	case msg == "OK":
+		return Result{Clean: true}, nil
+	case strings.HasSuffix(msg, " FOUND"):
+		return Result{Signature: strings.TrimSuffix(msg, " FOUND")}, nil
+	case strings.HasSuffix(msg, " ERROR"):
+		return Result{}, errors.New("clamd: " + strings.TrimSuffix(msg, " ERROR"))
+	}
+	return Result{}, fmt.Errorf("clamd: unexpected reply %q", line)
+}
@@ -0,0 +1,192 @@
+package scan
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeClamd accepts connections on a local port and hands each to serve.
+func fakeClamd(t *testing.T, serve func(net.Conn)) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				serve(conn)
+			}()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+// readStream reads an INSTREAM command and returns the bytes streamed, or an
+// error once more than limit bytes arrived (limit 0 means no limit).
+func readStream(conn net.Conn, limit int) ([]byte, error) {
+	r := bufio.NewReader(conn)
+	cmd, err := r.ReadString(0)
+	if err != nil {
+		return nil, err
+	}
+	if cmd != "zINSTREAM\x00" {
+		return nil, io.ErrUnexpectedEOF
+	}
+	var data bytes.Buffer
+	for {
+		var n uint32
+		if err := binary.Read(r, binary.BigEndian, &n); err != nil {
+			return nil, err
+		}
+		if n == 0 {
+			return data.Bytes(), nil
+		}
+		if limit > 0 && data.Len()+int(n) > limit {
+			return nil, errLimit
+		}
+		if _, err := io.CopyN(&data, r, int64(n)); err != nil {
+			return nil, err
+		}
+	}
+}
+
+var errLimit = errors.New("stream size limit exceeded")
+
+// replyWith answers every scan with line once the stream is read.
+func replyWith(line string) func(net.Conn) {
+	return func(conn net.Conn) {
+		if _, err := readStream(conn, 0); err != nil {
+			return
+		}
+		conn.Write([]byte(line + "\x00"))
+	}
+}
+
+func TestClamAVClean(t *testing.T) {
+	payload := bytes.Repeat([]byte("framelane"), 1000)
+	got := make(chan []byte, 1)
+	addr := fakeClamd(t, func(conn net.Conn) {
+		data, err := readStream(conn, 0)
+		if err != nil {
+			return
+		}
+		got <- data
+		conn.Write([]byte("stream: OK\x00"))
+	})
+
+	s := &ClamAV{Addr: addr, Timeout: 5 * time.Second, ChunkSize: 1000}
+	res, err := s.Scan(context.Background(), bytes.NewReader(payload))
+	if err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	if !res.Clean {
+		t.Fatalf("result %+v, want clean", res)
+	}
+	if data := <-got; !bytes.Equal(data, payload) {
+		t.Fatalf("clamd received %d bytes, want the %d sent", len(data), len(payload))
+	}
+}
+
+func TestClamAVFound(t *testing.T) {
+	addr := fakeClamd(t, replyWith("stream: Eicar-Test-Signature FOUND"))
+
+	s := &ClamAV{Addr: addr, Timeout: 5 * time.Second}
+	res, err := s.Scan(context.Background(), strings.NewReader("X5O!P%@AP[4\\PZX54(P^)7CC)7}$EICAR"))
+	if err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	if res.Clean || res.Signature != "Eicar-Test-Signature" {
+		t.Fatalf("result %+v, want Eicar-Test-Signature", res)
+	}
+}
+
+func TestClamAVError(t *testing.T) {
+	addr := fakeClamd(t, replyWith("stream: Can't allocate memory ERROR"))
+
+	s := &ClamAV{Addr: addr, Timeout: 5 * time.Second}
+	res, err := s.Scan(context.Background(), strings.NewReader("hello"))
+	if err == nil || !strings.Contains(err.Error(), "Can't allocate memory") {
+		t.Fatalf("err = %v, want clamd's error", err)
+	}
+	if res.Clean {
+		t.Fatal("an error reply was reported clean")
+	}
+}
+
+func TestClamAVUnexpectedReply(t *testing.T) {
+	addr := fakeClamd(t, replyWith("PONG"))
+
+	s := &ClamAV{Addr: addr, Timeout: 5 * time.Second}
+	if res, err := s.Scan(context.Background(), strings.NewReader("hello")); err == nil || res.Clean {
+		t.Fatalf("got %+v, %v; want an error", res, err)
+	}
+}
+
+// clamd answers and hangs up as soon as the stream passes StreamMaxLength,
+// while the client is still sending.
+func TestClamAVSizeLimitHangUp(t *testing.T) {
+	addr := fakeClamd(t, func(conn net.Conn) {
+		if _, err := readStream(conn, 64<<10); err != errLimit {
+			return
+		}
+		conn.Write([]byte("INSTREAM size limit exceeded. ERROR\x00"))
+	})
+
+	s := &ClamAV{Addr: addr, Timeout: 5 * time.Second, ChunkSize: 16 << 10}
+	start := time.Now()
+	res, err := s.Scan(context.Background(), bytes.NewReader(make([]byte, 32<<20)))
+	if err == nil || res.Clean {
+		t.Fatalf("got %+v, %v; want an error", res, err)
+	}
+	if time.Since(start) > 4*time.Second {
+		t.Fatalf("scan took %v after clamd hung up", time.Since(start))
+	}
+}
+
+func TestClamAVTimeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	addr := fakeClamd(t, func(conn net.Conn) {
+		readStream(conn, 0)
+		<-release // never answers
+	})
+
+	s := &ClamAV{Addr: addr, Timeout: 200 * time.Millisecond}
+	start := time.Now()
+	res, err := s.Scan(context.Background(), strings.NewReader("hello"))
+	if err == nil || res.Clean {
+		t.Fatalf("got %+v, %v; want a timeout", res, err)
+	}
+	if time.Since(start) > 2*time.Second {
+		t.Fatalf("scan took %v with a 200ms timeout", time.Since(start))
+	}
+}
+
+func TestClamAVUnreachable(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	s := &ClamAV{Addr: addr, Timeout: time.Second}
+	if res, err := s.Scan(context.Background(), strings.NewReader("hello")); err == nil || res.Clean {
+		t.Fatalf("got %+v, %v; want an error", res, err)
+	}
+}
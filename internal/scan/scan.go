@@ -0,0 +1,27 @@
+// Package scan checks uploaded files for malware before they enter the
+// production workflow.
+package scan
+
+import (
+	"context"
+	"io"
+)
+
+// Result is a scanner's verdict on one file.
+type Result struct {
+	Clean     bool   `json:"clean"`
+	Signature string `json:"signature,omitempty"` // what was found, when not clean
+}
+
+// Scanner inspects a stream. An error means the file could not be scanned,
+// not that it is infected.
+type Scanner interface {
+	Scan(ctx context.Context, r io.Reader) (Result, error)
+}
+
+// NoOp accepts everything. It is the default when no scanner is configured.
+type NoOp struct{}
+
+func (NoOp) Scan(ctx context.Context, r io.Reader) (Result, error) {
+	return Result{Clean: true}, nil
+}
@@ -0,0 +1,291 @@
+// Package seed loads the starter catalogue (sizes, frames and add-on options)
+// from a versioned YAML or JSON file. Each version is applied once and only
+// creates missing rows, so admin edits survive; force re-applies a version
+// and overwrites seeded fields.
+package seed
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
+
+	"github.com/olamideolayemi/framelane-api/internal/models"
+	"github.com/olamideolayemi/framelane-api/internal/pricing"
+)
+
+// DefaultFile is the seed file the CLI uses when none is given.
+const DefaultFile = "seeds/catalog.yaml"
+
+var (
+	// ErrApplied is returned when the file's version has already been applied.
+	ErrApplied = errors.New("seed version already applied")
+	// ErrChanged is returned when the file differs from the one its version
+	// was applied from, i.e. it was edited without bumping the version.
+	ErrChanged = errors.New("seed file changed since its version was applied")
+)
+
+type File struct {
+	Version int           `yaml:"version"`
+	Sizes   []Size        `yaml:"sizes"`
+	Frames  []Frame       `yaml:"frames"`
+	Options []OptionGroup `yaml:"options"`
+
+	path, checksum string
+}
+
+type Size struct {
+	Name         string  `yaml:"name"`
+	Price        int     `yaml:"price"`
+	Width        float64 `yaml:"width"` // read from the name when omitted
+	Height       float64 `yaml:"height"`
+	Unit         string  `yaml:"unit"`
+	DisplayOrder int     `yaml:"displayOrder"`
+}
+
+type Frame struct {
+	Name            string  `yaml:"name"`
+	Slug            string  `yaml:"slug"`
+	Description     string  `yaml:"description"`
+	Material        string  `yaml:"material"`
+	Color           string  `yaml:"color"`
+	MouldingWidthIn float64 `yaml:"mouldingWidthIn"`
+	DisplayOrder    int     `yaml:"displayOrder"`
+}
+
+type OptionGroup struct {
+	Name         string         `yaml:"name"`
+	Description  string         `yaml:"description"`
+	Required     bool           `yaml:"required"`
+	MultiSelect  bool           `yaml:"multiSelect"`
+	DisplayOrder int            `yaml:"displayOrder"`
+	Choices      []OptionChoice `yaml:"choices"`
+}
+
+type OptionChoice struct {
+	Name       string `yaml:"name"`
+	PriceDelta int    `yaml:"priceDelta"`
+	Default    bool   `yaml:"default"`
+}
+
+// Load reads and checks a seed file. JSON files are read by the YAML parser.
+// Unknown fields are errors, so a misspelt key isn't silently dropped.
+func Load(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var f File
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&f); err == io.EOF {
+		return nil, fmt.Errorf("%s: file is empty", path)
+	} else if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if f.Version < 1 {
+		return nil, fmt.Errorf("%s: version must be a positive number", path)
+	}
+	for i := range f.Sizes {
+		s := &f.Sizes[i]
+		if s.Name == "" || s.Price <= 0 {
+			return nil, fmt.Errorf("%s: size %d needs a name and a positive price", path, i+1)
+		}
+		if s.Width == 0 && s.Height == 0 {
+			w, h, unit, ok := models.ParseSizeName(s.Name)
+			if !ok {
+				return nil, fmt.Errorf("%s: size %q needs width and height", path, s.Name)
+			}
+			s.Width, s.Height, s.Unit = w, h, unit
+		}
+		if s.Unit == "" {
+			s.Unit = models.UnitInch
+		}
+		if s.Width <= 0 || s.Height <= 0 || !models.ValidUnit(s.Unit) {
+			return nil, fmt.Errorf("%s: size %q has invalid dimensions", path, s.Name)
+		}
+		if s.DisplayOrder == 0 {
+			s.DisplayOrder = (i + 1) * 10
+		}
+	}
+	for i := range f.Frames {
+		fr := &f.Frames[i]
+		if fr.Slug == "" {
+			fr.Slug = models.DefaultSlug(fr.Name)
+		}
+		if fr.Name == "" || !models.ValidSlug(fr.Slug) {
+			return nil, fmt.Errorf("%s: frame %d needs a name and a valid slug", path, i+1)
+		}
+	}
+	for i, g := range f.Options {
+		if g.Name == "" || len(g.Choices) == 0 {
+			return nil, fmt.Errorf("%s: option group %d needs a name and choices", path, i+1)
+		}
+	}
+	sum := sha256.Sum256(data)
+	f.path, f.checksum = path, hex.EncodeToString(sum[:])
+	return &f, nil
+}
+
+// Result counts what Apply did.
+type Result struct {
+	Version int
+	Created int
+	Updated int
+	Skipped int // already present and left alone
+}
+
+// Apply seeds the catalogue in one transaction. Without force an applied
+// version returns ErrApplied, or ErrChanged if the file no longer matches the
+// checksum it was applied with, and existing rows are never changed.
+func Apply(db *gorm.DB, f *File, force bool) (*Result, error) {
+	res := &Result{Version: f.Version}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var prev models.SeedRun
+		err := tx.First(&prev, "version = ?", f.Version).Error
+		switch {
+		case err == nil && !force && prev.Checksum != f.checksum:
+			return ErrChanged
+		case err == nil && !force:
+			return ErrApplied
+		case err != nil && !errors.Is(err, gorm.ErrRecordNotFound):
+			return err
+		}
+		for _, s := range f.Sizes {
+			if err := applySize(tx, s, force, res); err != nil {
+				return fmt.Errorf("size %q: %w", s.Name, err)
+			}
+		}
+		for _, fr := range f.Frames {
+			if err := applyFrame(tx, fr, force, res); err != nil {
+				return fmt.Errorf("frame %q: %w", fr.Name, err)
+			}
+		}
+		for _, g := range f.Options {
+			if err := applyOptionGroup(tx, g, force, res); err != nil {
+				return fmt.Errorf("option group %q: %w", g.Name, err)
+			}
+		}
+		return tx.Save(&models.SeedRun{
+			Version: f.Version, File: f.path, Checksum: f.checksum, Forced: force,
+			Created: res.Created, Updated: res.Updated, AppliedAt: time.Now(),
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func applySize(tx *gorm.DB, s Size, force bool, res *Result) error {
+	var size models.FrameSize
+	err := tx.Unscoped().First(&size, "LOWER(name) = ?", strings.ToLower(s.Name)).Error
+	isNew := errors.Is(err, gorm.ErrRecordNotFound)
+	if err != nil && !isNew {
+		return err
+	}
+	if size.DeletedAt.Valid || (!isNew && !force) { // archived sizes stay archived
+		res.Skipped++
+		return nil
+	}
+	if isNew {
+		size = models.FrameSize{ID: uuid.New(), Status: "available"}
+	}
+	priceChanged := isNew || size.Price != s.Price
+	size.Name, size.Price, size.DisplayOrder = s.Name, s.Price, s.DisplayOrder
+	size.SetDimensions(s.Width, s.Height, s.Unit)
+	if err := tx.Save(&size).Error; err != nil {
+		return err
+	}
+	count(res, isNew)
+	if priceChanged {
+		return pricing.Record(tx, size.ID, size.Price, fmt.Sprintf("seed v%d", res.Version), nil)
+	}
+	return nil
+}
+
+func applyFrame(tx *gorm.DB, fr Frame, force bool, res *Result) error {
+	var frame models.Frame
+	err := tx.Unscoped().First(&frame, "slug = ? OR LOWER(name) = ?", fr.Slug, strings.ToLower(fr.Name)).Error
+	isNew := errors.Is(err, gorm.ErrRecordNotFound)
+	if err != nil && !isNew {
+		return err
+	}
+	if frame.DeletedAt.Valid || (!isNew && !force) { // archived frames stay archived
+		res.Skipped++
+		return nil
+	}
+	if isNew {
+		frame = models.Frame{ID: uuid.New(), Status: "available"}
+	}
+	frame.Name, frame.Slug, frame.Description = fr.Name, fr.Slug, fr.Description
+	frame.Material, frame.Color = fr.Material, fr.Color
+	frame.MouldingWidthIn, frame.DisplayOrder = fr.MouldingWidthIn, fr.DisplayOrder
+	if err := tx.Omit("Images").Save(&frame).Error; err != nil {
+		return err
+	}
+	count(res, isNew)
+	return nil
+}
+
+func applyOptionGroup(tx *gorm.DB, g OptionGroup, force bool, res *Result) error {
+	var group models.OptionGroup
+	err := tx.First(&group, "LOWER(name) = ?", strings.ToLower(g.Name)).Error
+	isNew := errors.Is(err, gorm.ErrRecordNotFound)
+	if err != nil && !isNew {
+		return err
+	}
+	if isNew || force {
+		if isNew {
+			group = models.OptionGroup{ID: uuid.New()}
+		}
+		group.Name, group.Description = g.Name, g.Description
+		group.Required, group.MultiSelect, group.DisplayOrder = g.Required, g.MultiSelect, g.DisplayOrder
+		if err := tx.Omit("Choices").Save(&group).Error; err != nil {
+			return err
+		}
+		count(res, isNew)
+	} else {
+		res.Skipped++
+	}
+
+	for i, ch := range g.Choices {
+		var choice models.OptionChoice
+		err := tx.First(&choice, "group_id = ? AND LOWER(name) = ?", group.ID, strings.ToLower(ch.Name)).Error
+		isNew := errors.Is(err, gorm.ErrRecordNotFound)
+		if err != nil && !isNew {
+			return err
+		}
+		if !isNew && !force {
+			res.Skipped++
+			continue
+		}
+		if isNew {
+			choice = models.OptionChoice{ID: uuid.New(), GroupID: group.ID, Status: "available"}
+		}
+		choice.Name, choice.PriceDelta, choice.IsDefault = ch.Name, ch.PriceDelta, ch.Default
+		choice.DisplayOrder = (i + 1) * 10
+		if err := tx.Save(&choice).Error; err != nil {
+			return err
+		}
+		count(res, isNew)
+	}
+	return nil
+}
+
+func count(res *Result, created bool) {
+	if created {
+		res.Created++
+	} else {
+		res.Updated++
+	}
+}
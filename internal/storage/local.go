@@ -0,0 +1,452 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LocalRoute is where the API serves signed local-storage URLs.
+const LocalRoute = "/v1/storage"
+
+// Local keeps objects on disk for development and tests. Presigned URLs point
+// back at the API (see LocalRoute) and carry an HMAC instead of an S3 signature.
+type Local struct {
+	Root    string // objects live under Root/objects, metadata under Root/meta
+	BaseURL string // public URL of the API, e.g. http://localhost:8080
+	Secret  []byte
+	ViewTTL time.Duration
+}
+
+func NewLocal(root, baseURL string, secret []byte) (*Local, error) {
+	for _, d := range []string{"objects", "meta", "multipart"} {
+		if err := os.MkdirAll(filepath.Join(root, d), 0o755); err != nil {
+			return nil, err
+		}
+	}
+	return &Local{Root: root, BaseURL: strings.TrimRight(baseURL, "/"), Secret: secret}, nil
+}
+
+// Signed URL operations
+const (
+	LocalOpGet  = "get"
+	LocalOpPut  = "put"
+	LocalOpPost = "post"
+	LocalOpPart = "part"
+)
+
+// LocalGrant is what a verified signed URL allows.
+type LocalGrant struct {
+	Op           string
+	Key          string
+	ContentType  string
+	MaxBytes     int64
+	DownloadName string
+	UploadID     string // multipart parts only
+	Part         int
+	Expires      time.Time
+}
+
+func (l *Local) sign(g LocalGrant) string {
+	m := hmac.New(sha256.New, l.Secret)
+	fmt.Fprintf(m, "%s\n%s\n%s\n%d\n%s\n%s\n%d\n%d", g.Op, g.Key, g.ContentType, g.MaxBytes, g.DownloadName, g.UploadID, g.Part, g.Expires.Unix())
+	return hex.EncodeToString(m.Sum(nil))
+}
+
+func (l *Local) params(g LocalGrant) url.Values {
+	v := url.Values{}
+	v.Set("key", g.Key)
+	v.Set("expires", strconv.FormatInt(g.Expires.Unix(), 10))
+	if g.ContentType != "" {
+		v.Set("Content-Type", g.ContentType)
+	}
+	if g.MaxBytes > 0 {
+		v.Set("max", strconv.FormatInt(g.MaxBytes, 10))
+	}
+	if g.DownloadName != "" {
+		v.Set("filename", g.DownloadName)
+	}
+	if g.UploadID != "" {
+		v.Set("uploadId", g.UploadID)
+		v.Set("partNumber", strconv.Itoa(g.Part))
+	}
+	v.Set("signature", l.sign(g))
+	return v
+}
+
+// Verify checks the fields of a signed URL or upload form for op.
+func (l *Local) Verify(op string, get func(string) string) (*LocalGrant, error) {
+	exp, err := strconv.ParseInt(get("expires"), 10, 64)
+	if err != nil {
+		return nil, errors.New("missing expiry")
+	}
+	g := LocalGrant{
+		Op:           op,
+		Key:          get("key"),
+		ContentType:  get("Content-Type"),
+		DownloadName: get("filename"),
+		UploadID:     get("uploadId"),
+		Expires:      time.Unix(exp, 0),
+	}
+	if g.UploadID != "" {
+		if g.Part, err = strconv.Atoi(get("partNumber")); err != nil {
+			return nil, errors.New("bad part number")
+		}
+	}
+	if m := get("max"); m != "" {
+		if g.MaxBytes, err = strconv.ParseInt(m, 10, 64); err != nil {
+			return nil, errors.New("bad size limit")
+		}
+	}
+	if !hmac.Equal([]byte(l.sign(g)), []byte(get("signature"))) {
+		return nil, errors.New("bad signature")
+	}
+	if time.Now().After(g.Expires) {
+		return nil, errors.New("link expired")
+	}
+	if _, err := l.path("objects", g.Key); err != nil {
+		return nil, err
+	}
+	return &g, nil
+}
+
+func (l *Local) PresignPut(ctx context.Context, objectName string, contentType string, expire time.Duration) (string, error) {
+	g := LocalGrant{Op: LocalOpPut, Key: objectName, ContentType: contentType, Expires: time.Now().Add(expire)}
+	return l.BaseURL + LocalRoute + "/object?" + l.params(g).Encode(), nil
+}
+
+// PresignPost returns form fields mirroring an S3 POST policy: the key, type
+// and size cap are signed, and the file goes in a "file" field.
+func (l *Local) PresignPost(ctx context.Context, objectName string, contentType string, maxBytes int64, expire time.Duration) (string, map[string]string, error) {
+	g := LocalGrant{Op: LocalOpPost, Key: objectName, ContentType: contentType, MaxBytes: maxBytes, Expires: time.Now().Add(expire)}
+	fields := map[string]string{}
+	for k, v := range l.params(g) {
+		fields[k] = v[0]
+	}
+	return l.BaseURL + LocalRoute + "/upload", fields, nil
+}
+
+func (l *Local) PresignGet(ctx context.Context, objectName string, downloadName string, expire time.Duration) (string, error) {
+	g := LocalGrant{Op: LocalOpGet, Key: objectName, DownloadName: downloadName, Expires: time.Now().Add(expire)}
+	return l.BaseURL + LocalRoute + "/object?" + l.params(g).Encode(), nil
+}
+
+func (l *Local) ViewURL(ctx context.Context, objectName string) string {
+	if objectName == "" {
+		return ""
+	}
+	ttl := l.ViewTTL
+	if ttl <= 0 {
+		ttl = DefaultViewTTL
+	}
+	u, _ := l.PresignGet(ctx, objectName, "", ttl)
+	return u
+}
+
+// KeyFromURL never matches: local storage has no plain object URLs.
+func (l *Local) KeyFromURL(raw string) (string, bool) {
+	return "", false
+}
+
+// path maps a key to a file under Root/dir, refusing keys that escape it.
+func (l *Local) path(dir, key string) (string, error) {
+	if key == "" || strings.HasPrefix(key, "/") || path.Clean(key) != key || strings.HasPrefix(key, "../") || key == ".." {
+		return "", fmt.Errorf("invalid object key %q", key)
+	}
+	return filepath.Join(l.Root, dir, filepath.FromSlash(key)), nil
+}
+
+type localMeta struct {
+	ContentType string `json:"contentType"`
+}
+
+func (l *Local) Stat(ctx context.Context, objectName string) (*ObjectInfo, error) {
+	p, err := l.path("objects", objectName)
+	if err != nil {
+		return nil, err
+	}
+	fi, err := os.Stat(p)
+	if errors.Is(err, fs.ErrNotExist) || err == nil && fi.IsDir() {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &ObjectInfo{Key: objectName, Size: fi.Size(), ContentType: l.contentType(objectName), LastModified: fi.ModTime()}, nil
+}
+
+func (l *Local) contentType(objectName string) string {
+	if mp, err := l.path("meta", objectName); err == nil {
+		if raw, err := os.ReadFile(mp + ".json"); err == nil {
+			var m localMeta
+			if json.Unmarshal(raw, &m) == nil && m.ContentType != "" {
+				return m.ContentType
+			}
+		}
+	}
+	if ct := mime.TypeByExtension(path.Ext(objectName)); ct != "" {
+		return ct
+	}
+	return "application/octet-stream"
+}
+
+// Get opens an object for reading; the returned file is also an io.Seeker.
+func (l *Local) Get(ctx context.Context, objectName string) (io.ReadCloser, error) {
+	p, err := l.path("objects", objectName)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return f, err
+}
+
+// Put writes to a temp file first so readers never see a partial object.
+func (l *Local) Put(ctx context.Context, objectName string, r io.Reader, size int64, contentType string) error {
+	p, err := l.path("objects", objectName)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(p), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	n, err := io.Copy(tmp, r)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	if size >= 0 && n != size {
+		return fmt.Errorf("short write: got %d of %d bytes", n, size)
+	}
+
+	mp, _ := l.path("meta", objectName)
+	if err := os.MkdirAll(filepath.Dir(mp), 0o755); err != nil {
+		return err
+	}
+	meta, _ := json.Marshal(localMeta{ContentType: contentType})
+	if err := os.WriteFile(mp+".json", meta, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), p)
+}
+
+func (l *Local) Delete(ctx context.Context, objectName string) error {
+	p, err := l.path("objects", objectName)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	mp, _ := l.path("meta", objectName)
+	_ = os.Remove(mp + ".json")
+	return nil
+}
+
+func (l *Local) Copy(ctx context.Context, src, dst string) error {
+	st, err := l.Stat(ctx, src)
+	if err != nil {
+		return err
+	}
+	r, err := l.Get(ctx, src)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	return l.Put(ctx, dst, r, st.Size, st.ContentType)
+}
+
+// List returns every object under prefix.
+func (l *Local) List(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	root := filepath.Join(l.Root, "objects")
+	var out []ObjectInfo
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(root, p)
+		key := filepath.ToSlash(rel)
+		if d.IsDir() {
+			// Skip directories that can't contain a match
+			if key != "." && !strings.HasPrefix(key+"/", prefix) && !strings.HasPrefix(prefix, key+"/") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasPrefix(d.Name(), ".upload-") || !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		out = append(out, ObjectInfo{Key: key, Size: fi.Size(), ContentType: l.contentType(key), LastModified: fi.ModTime()})
+		return nil
+	})
+	return out, err
+}
+
+// Multipart uploads keep their parts under Root/multipart/<uploadID>/ until
+// completion concatenates them into the object.
+
+func (l *Local) uploadDir(objectName, uploadID string) (string, error) {
+	if _, err := hex.DecodeString(uploadID); err != nil || len(uploadID) != 32 {
+		return "", errors.New("invalid upload ID")
+	}
+	dir := filepath.Join(l.Root, "multipart", uploadID)
+	key, err := os.ReadFile(filepath.Join(dir, "key"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	if string(key) != objectName {
+		return "", errors.New("upload belongs to another object")
+	}
+	return dir, nil
+}
+
+func (l *Local) NewMultipart(ctx context.Context, objectName string, contentType string) (string, error) {
+	if _, err := l.path("objects", objectName); err != nil {
+		return "", err
+	}
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	id := hex.EncodeToString(b)
+	dir := filepath.Join(l.Root, "multipart", id)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "content-type"), []byte(contentType), 0o644); err != nil {
+		return "", err
+	}
+	return id, os.WriteFile(filepath.Join(dir, "key"), []byte(objectName), 0o644)
+}
+
+func (l *Local) PresignPart(ctx context.Context, objectName string, uploadID string, part int, expire time.Duration) (string, error) {
+	g := LocalGrant{Op: LocalOpPart, Key: objectName, UploadID: uploadID, Part: part, Expires: time.Now().Add(expire)}
+	return l.BaseURL + LocalRoute + "/part?" + l.params(g).Encode(), nil
+}
+
+// PutPart stores one part and returns its ETag.
+func (l *Local) PutPart(ctx context.Context, objectName string, uploadID string, part int, r io.Reader) (string, error) {
+	dir, err := l.uploadDir(objectName, uploadID)
+	if err != nil {
+		return "", err
+	}
+	if part < 1 || part > MaxParts {
+		return "", errors.New("invalid part number")
+	}
+	tmp, err := os.CreateTemp(dir, ".part-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+	h := sha256.New()
+	_, err = io.Copy(io.MultiWriter(tmp, h), r)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return "", err
+	}
+	etag := hex.EncodeToString(h.Sum(nil))[:32]
+	if err := os.Rename(tmp.Name(), filepath.Join(dir, fmt.Sprintf("%05d.%s", part, etag))); err != nil {
+		return "", err
+	}
+	// A re-uploaded part replaces the earlier copy
+	old, _ := filepath.Glob(filepath.Join(dir, fmt.Sprintf("%05d.*", part)))
+	for _, f := range old {
+		if !strings.HasSuffix(f, "."+etag) {
+			os.Remove(f)
+		}
+	}
+	return etag, nil
+}
+
+func (l *Local) ListParts(ctx context.Context, objectName string, uploadID string) ([]Part, error) {
+	dir, err := l.uploadDir(objectName, uploadID)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var parts []Part
+	for _, e := range entries {
+		num, etag, ok := strings.Cut(e.Name(), ".")
+		n, err := strconv.Atoi(num)
+		if !ok || err != nil || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		fi, err := e.Info()
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, Part{Number: n, ETag: etag, Size: fi.Size()})
+	}
+	return parts, nil // ReadDir sorts by name, and names are zero-padded
+}
+
+func (l *Local) CompleteMultipart(ctx context.Context, objectName string, uploadID string, parts []Part) error {
+	dir, err := l.uploadDir(objectName, uploadID)
+	if err != nil {
+		return err
+	}
+	ct, _ := os.ReadFile(filepath.Join(dir, "content-type"))
+
+	readers := make([]io.Reader, 0, len(parts))
+	var size int64
+	for _, p := range parts {
+		f, err := os.Open(filepath.Join(dir, fmt.Sprintf("%05d.%s", p.Number, p.ETag)))
+		if err != nil {
+			return fmt.Errorf("part %d: %w", p.Number, err)
+		}
+		defer f.Close()
+		readers = append(readers, f)
+		size += p.Size
+	}
+	if err := l.Put(ctx, objectName, io.MultiReader(readers...), size, string(ct)); err != nil {
+		return err
+	}
+	return os.RemoveAll(dir)
+}
+
+func (l *Local) AbortMultipart(ctx context.Context, objectName string, uploadID string) error {
+	dir, err := l.uploadDir(objectName, uploadID)
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(dir)
+}
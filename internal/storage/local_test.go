@@ -0,0 +1,269 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestLocal(t *testing.T) *Local {
+	t.Helper()
+	l, err := NewLocal(t.TempDir(), "http://api.test/", []byte("test-secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return l
+}
+
+// query returns the parameters of a presigned local URL.
+func query(t *testing.T, raw string) url.Values {
+	t.Helper()
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u.Query()
+}
+
+func TestLocalVerifyRoundTrip(t *testing.T) {
+	l := newTestLocal(t)
+	ctx := context.Background()
+
+	raw, err := l.PresignGet(ctx, "uploads/a.jpg", "photo.jpg", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(raw, "http://api.test"+LocalRoute+"/object?") {
+		t.Fatalf("url %q not under %s", raw, LocalRoute)
+	}
+	g, err := l.Verify(LocalOpGet, query(t, raw).Get)
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if g.Key != "uploads/a.jpg" || g.DownloadName != "photo.jpg" {
+		t.Fatalf("grant %+v", g)
+	}
+
+	_, fields, err := l.PresignPost(ctx, "uploads/b.png", "image/png", 1<<20, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err = l.Verify(LocalOpPost, func(k string) string { return fields[k] })
+	if err != nil {
+		t.Fatalf("verify post: %v", err)
+	}
+	if g.ContentType != "image/png" || g.MaxBytes != 1<<20 {
+		t.Fatalf("post grant %+v", g)
+	}
+
+	raw, err = l.PresignPart(ctx, "uploads/c.tif", strings.Repeat("ab", 16), 3, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g, err = l.Verify(LocalOpPart, query(t, raw).Get); err != nil || g.Part != 3 {
+		t.Fatalf("verify part: %+v, %v", g, err)
+	}
+}
+
+func TestLocalVerifyRejects(t *testing.T) {
+	l := newTestLocal(t)
+	ctx := context.Background()
+	_, fields, err := l.PresignPost(ctx, "uploads/a.jpg", "image/jpeg", 1<<20, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tampered := map[string]string{
+		"key":          "uploads/other.jpg",
+		"Content-Type": "text/html",
+		"max":          "999999999",
+		"expires":      "99999999999",
+	}
+	for field, v := range tampered {
+		t.Run(field, func(t *testing.T) {
+			f := map[string]string{}
+			for k, v := range fields {
+				f[k] = v
+			}
+			f[field] = v
+			if _, err := l.Verify(LocalOpPost, func(k string) string { return f[k] }); err == nil {
+				t.Fatalf("tampered %s accepted", field)
+			}
+		})
+	}
+
+	t.Run("op", func(t *testing.T) {
+		if _, err := l.Verify(LocalOpPut, func(k string) string { return fields[k] }); err == nil {
+			t.Fatal("post grant accepted for put")
+		}
+	})
+
+	t.Run("secret", func(t *testing.T) {
+		other := &Local{Root: l.Root, Secret: []byte("another-secret")}
+		if _, err := other.Verify(LocalOpPost, func(k string) string { return fields[k] }); err == nil {
+			t.Fatal("grant accepted under another secret")
+		}
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		raw, err := l.PresignGet(ctx, "uploads/a.jpg", "", -time.Second)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := l.Verify(LocalOpGet, query(t, raw).Get); err == nil || !strings.Contains(err.Error(), "expired") {
+			t.Fatalf("err = %v, want link expired", err)
+		}
+	})
+}
+
+func TestLocalPath(t *testing.T) {
+	l := newTestLocal(t)
+	for _, key := range []string{"", "..", "../etc/passwd", "a/../../b", "/etc/passwd", "a/../b", "./a", "a//b", "a/", "a/."} {
+		if _, err := l.path("objects", key); err == nil {
+			t.Errorf("key %q accepted", key)
+		}
+	}
+	for _, key := range []string{"a", "uploads/2024/a.jpg", "a..b/c"} {
+		if _, err := l.path("objects", key); err != nil {
+			t.Errorf("key %q: %v", key, err)
+		}
+	}
+}
+
+func TestLocalObjects(t *testing.T) {
+	l := newTestLocal(t)
+	ctx := context.Background()
+	body := []byte("framelane")
+
+	if err := l.Put(ctx, "uploads/u1/a.jpg", bytes.NewReader(body), int64(len(body)), "image/jpeg"); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	if err := l.Put(ctx, "uploads/u1/short.jpg", bytes.NewReader(body), 100, "image/jpeg"); err == nil {
+		t.Fatal("short put accepted")
+	}
+	if err := l.Put(ctx, "../escape", bytes.NewReader(body), -1, ""); err == nil {
+		t.Fatal("put outside the root accepted")
+	}
+
+	st, err := l.Stat(ctx, "uploads/u1/a.jpg")
+	if err != nil {
+		t.Fatalf("stat: %v", err)
+	}
+	if st.Size != int64(len(body)) || st.ContentType != "image/jpeg" {
+		t.Fatalf("stat %+v", st)
+	}
+	if _, err := l.Stat(ctx, "uploads/u1/missing.jpg"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("stat missing: %v, want ErrNotFound", err)
+	}
+	if _, err := l.Stat(ctx, "uploads/u1"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("stat directory: %v, want ErrNotFound", err)
+	}
+
+	r, err := l.Get(ctx, "uploads/u1/a.jpg")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	got, _ := io.ReadAll(r)
+	r.Close()
+	if !bytes.Equal(got, body) {
+		t.Fatalf("get = %q, want %q", got, body)
+	}
+	if _, err := l.Get(ctx, "uploads/u1/missing.jpg"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("get missing: %v, want ErrNotFound", err)
+	}
+
+	if err := l.Copy(ctx, "uploads/u1/a.jpg", "quarantine/u1/a.jpg"); err != nil {
+		t.Fatalf("copy: %v", err)
+	}
+	if st, err := l.Stat(ctx, "quarantine/u1/a.jpg"); err != nil || st.ContentType != "image/jpeg" {
+		t.Fatalf("copied %+v, %v", st, err)
+	}
+	if err := l.Copy(ctx, "uploads/u1/missing.jpg", "quarantine/u1/b.jpg"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("copy missing: %v, want ErrNotFound", err)
+	}
+
+	list, err := l.List(ctx, "uploads/")
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(list) != 1 || list[0].Key != "uploads/u1/a.jpg" {
+		t.Fatalf("list uploads/ = %+v", list)
+	}
+	if list, _ := l.List(ctx, "up"); len(list) != 1 {
+		t.Fatalf("list by partial prefix = %+v", list)
+	}
+	if list, _ := l.List(ctx, ""); len(list) != 2 {
+		t.Fatalf("list all = %+v", list)
+	}
+
+	if err := l.Delete(ctx, "uploads/u1/a.jpg"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, err := l.Stat(ctx, "uploads/u1/a.jpg"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("stat after delete: %v, want ErrNotFound", err)
+	}
+	if err := l.Delete(ctx, "uploads/u1/a.jpg"); err != nil {
+		t.Fatalf("delete twice: %v", err)
+	}
+}
+
+func TestLocalMultipart(t *testing.T) {
+	l := newTestLocal(t)
+	ctx := context.Background()
+	key := "uploads/u1/big.tif"
+
+	id, err := l.NewMultipart(ctx, key, "image/tiff")
+	if err != nil {
+		t.Fatalf("new multipart: %v", err)
+	}
+	chunks := []string{"first part,", "second part,", "third part"}
+	for i := len(chunks) - 1; i >= 0; i-- { // parts may arrive in any order
+		if _, err := l.PutPart(ctx, key, id, i+1, strings.NewReader(chunks[i])); err != nil {
+			t.Fatalf("put part %d: %v", i+1, err)
+		}
+	}
+	// A retried part replaces the earlier upload
+	if _, err := l.PutPart(ctx, key, id, 2, strings.NewReader("second part,")); err != nil {
+		t.Fatalf("re-put part 2: %v", err)
+	}
+	if _, err := l.PutPart(ctx, "uploads/u1/other.tif", id, 1, strings.NewReader("x")); err == nil {
+		t.Fatal("part accepted for another object")
+	}
+
+	parts, err := l.ListParts(ctx, key, id)
+	if err != nil {
+		t.Fatalf("list parts: %v", err)
+	}
+	if len(parts) != len(chunks) {
+		t.Fatalf("parts = %+v, want %d", parts, len(chunks))
+	}
+	for i, p := range parts {
+		if p.Number != i+1 || p.Size != int64(len(chunks[i])) {
+			t.Fatalf("part %d = %+v", i+1, p)
+		}
+	}
+
+	if err := l.CompleteMultipart(ctx, key, id, parts); err != nil {
+		t.Fatalf("complete: %v", err)
+	}
+	r, err := l.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	got, _ := io.ReadAll(r)
+	r.Close()
+	if want := strings.Join(chunks, ""); string(got) != want {
+		t.Fatalf("object = %q, want %q", got, want)
+	}
+	if st, _ := l.Stat(ctx, key); st == nil || st.ContentType != "image/tiff" {
+		t.Fatalf("stat %+v, want image/tiff", st)
+	}
+	if _, err := l.ListParts(ctx, key, id); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("list parts after complete: %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,80 @@
+package storage
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// Part is an uploaded part of a multipart upload.
+type Part struct {
+	Number int    `json:"partNumber"`
+	ETag   string `json:"etag"`
+	Size   int64  `json:"size"`
+}
+
+// S3 requires every part but the last to be at least this big, and allows at most MaxParts.
+const (
+	MinPartSize = 5 << 20
+	MaxParts    = 10000
+)
+
+func (s *S3) core() minio.Core {
+	return minio.Core{Client: s.Client}
+}
+
+func (s *S3) NewMultipart(ctx context.Context, objectName string, contentType string) (string, error) {
+	return s.core().NewMultipartUpload(ctx, s.Bucket, objectName, minio.PutObjectOptions{ContentType: contentType})
+}
+
+// PresignPart returns a URL the client PUTs one part's bytes to. The ETag
+// response header is what S3 needs at completion; we read it back with ListParts.
+func (s *S3) PresignPart(ctx context.Context, objectName string, uploadID string, part int, expire time.Duration) (string, error) {
+	params := url.Values{}
+	params.Set("partNumber", strconv.Itoa(part))
+	params.Set("uploadId", uploadID)
+	u, err := s.Client.Presign(ctx, http.MethodPut, s.Bucket, objectName, expire, params)
+	if err != nil {
+		return "", err
+	}
+	return u.String(), nil
+}
+
+// ListParts returns the parts uploaded so far, in order.
+func (s *S3) ListParts(ctx context.Context, objectName string, uploadID string) ([]Part, error) {
+	var parts []Part
+	marker := 0
+	for {
+		res, err := s.core().ListObjectParts(ctx, s.Bucket, objectName, uploadID, marker, 1000)
+		if err != nil {
+			return nil, mapErr(err)
+		}
+		for _, p := range res.ObjectParts {
+			parts = append(parts, Part{Number: p.PartNumber, ETag: p.ETag, Size: p.Size})
+		}
+		if !res.IsTruncated {
+			break
+		}
+		marker = res.NextPartNumberMarker
+	}
+	sort.Slice(parts, func(i, j int) bool { return parts[i].Number < parts[j].Number })
+	return parts, nil
+}
+
+func (s *S3) CompleteMultipart(ctx context.Context, objectName string, uploadID string, parts []Part) error {
+	cp := make([]minio.CompletePart, len(parts))
+	for i, p := range parts {
+		cp[i] = minio.CompletePart{PartNumber: p.Number, ETag: p.ETag}
+	}
+	_, err := s.core().CompleteMultipartUpload(ctx, s.Bucket, objectName, uploadID, cp, minio.PutObjectOptions{})
+	return mapErr(err)
+}
+
+func (s *S3) AbortMultipart(ctx context.Context, objectName string, uploadID string) error {
+	return mapErr(s.core().AbortMultipartUpload(ctx, s.Bucket, objectName, uploadID))
+}
@@ -2,17 +2,28 @@ package storage
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/credentials"
 )
 
+// The bucket is private: objects are only ever reached through presigned URLs.
 type S3 struct {
-	Client *minio.Client
-	Bucket string
+	Client  *minio.Client
+	Bucket  string
+	ViewTTL time.Duration // lifetime of URLs minted by ViewURL
 }
 
+// DefaultViewTTL is used when ViewTTL is unset.
+const DefaultViewTTL = 15 * time.Minute
+
 func New(endpoint string, access string, secret string, useSSL bool, bucket string) (*S3, error) {
 	c, err := minio.New(endpoint, &minio.Options{
 		Creds:  credentials.NewStaticV4(access, secret, ""),
@@ -23,11 +34,154 @@ func New(endpoint string, access string, secret string, useSSL bool, bucket stri
 }
 
 func (s *S3) PresignPut(ctx context.Context, objectName string, contentType string, expire time.Duration) (string, error) {
-	reqParams := make(map[string]string)
+	// Sign the Content-Type header so the client has to upload with that type
+	headers := http.Header{}
 	if contentType != "" {
-		reqParams["response-content-type"] = contentType
+		headers.Set("Content-Type", contentType)
 	}
-	u, err := s.Client.PresignedPutObject(ctx, s.Bucket, objectName, expire)
+	u, err := s.Client.PresignHeader(ctx, http.MethodPut, s.Bucket, objectName, expire, nil, headers)
 	if err != nil { return "", err }
 	return u.String(), nil
 }
+
+// PresignPost returns a browser form upload URL and the form fields to send
+// with it. The policy pins the key and content type and caps the size, which
+// a presigned PUT can't do.
+func (s *S3) PresignPost(ctx context.Context, objectName string, contentType string, maxBytes int64, expire time.Duration) (string, map[string]string, error) {
+	p := minio.NewPostPolicy()
+	if err := p.SetBucket(s.Bucket); err != nil { return "", nil, err }
+	if err := p.SetKey(objectName); err != nil { return "", nil, err }
+	if err := p.SetExpires(time.Now().UTC().Add(expire)); err != nil { return "", nil, err }
+	if err := p.SetContentType(contentType); err != nil { return "", nil, err }
+	if err := p.SetContentLengthRange(1, maxBytes); err != nil { return "", nil, err }
+	u, fields, err := s.Client.PresignedPostPolicy(ctx, p)
+	if err != nil { return "", nil, err }
+	return u.String(), fields, nil
+}
+
+// ObjectInfo is the metadata Stat returns
+type ObjectInfo struct {
+	Key          string
+	Size         int64
+	ContentType  string
+	LastModified time.Time
+}
+
+func (s *S3) Stat(ctx context.Context, objectName string) (*ObjectInfo, error) {
+	st, err := s.Client.StatObject(ctx, s.Bucket, objectName, minio.StatObjectOptions{})
+	if err != nil { return nil, mapErr(err) }
+	return &ObjectInfo{Key: st.Key, Size: st.Size, ContentType: st.ContentType, LastModified: st.LastModified}, nil
+}
+
+// Get opens an object for reading; the caller closes it.
+func (s *S3) Get(ctx context.Context, objectName string) (io.ReadCloser, error) {
+	obj, err := s.Client.GetObject(ctx, s.Bucket, objectName, minio.GetObjectOptions{})
+	if err != nil { return nil, mapErr(err) }
+	return obj, nil
+}
+
+func (s *S3) Delete(ctx context.Context, objectName string) error {
+	return s.Client.RemoveObject(ctx, s.Bucket, objectName, minio.RemoveObjectOptions{})
+}
+
+func (s *S3) Copy(ctx context.Context, src, dst string) error {
+	_, err := s.Client.CopyObject(ctx,
+		minio.CopyDestOptions{Bucket: s.Bucket, Object: dst},
+		minio.CopySrcOptions{Bucket: s.Bucket, Object: src})
+	return mapErr(err)
+}
+
+// List returns every object under prefix.
+func (s *S3) List(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	var out []ObjectInfo
+	for o := range s.Client.ListObjects(ctx, s.Bucket, minio.ListObjectsOptions{Prefix: prefix, Recursive: true}) {
+		if o.Err != nil { return nil, o.Err }
+		out = append(out, ObjectInfo{Key: o.Key, Size: o.Size, ContentType: o.ContentType, LastModified: o.LastModified})
+	}
+	return out, nil
+}
+
+// mapErr turns a missing object or multipart upload into ErrNotFound.
+func mapErr(err error) error {
+	if err == nil {
+		return nil
+	}
+	switch minio.ToErrorResponse(err).Code {
+	case "NoSuchKey", "NoSuchUpload":
+		return ErrNotFound
+	}
+	return err
+}
+
+// PresignGet returns a time-limited download URL. When downloadName is set
+// the browser saves the file under that name instead of displaying it.
+func (s *S3) PresignGet(ctx context.Context, objectName string, downloadName string, expire time.Duration) (string, error) {
+	params := url.Values{}
+	if downloadName != "" {
+		params.Set("response-content-disposition", fmt.Sprintf("attachment; filename=%q", downloadName))
+	}
+	u, err := s.Client.PresignedGetObject(ctx, s.Bucket, objectName, expire, params)
+	if err != nil { return "", err }
+	return u.String(), nil
+}
+
+// ViewURL mints a short-lived URL for showing an object in the app. It returns
+// "" for an empty key or if signing fails.
+func (s *S3) ViewURL(ctx context.Context, objectName string) string {
+	if objectName == "" {
+		return ""
+	}
+	ttl := s.ViewTTL
+	if ttl <= 0 {
+		ttl = DefaultViewTTL
+	}
+	u, err := s.PresignGet(ctx, objectName, "", ttl)
+	if err != nil { return "" }
+	return u
+}
+
+// KeyFromURL recovers the object key from a plain object URL of this bucket,
+// as stored on orders before the bucket went private.
+func (s *S3) KeyFromURL(raw string) (string, bool) {
+	prefix := s.Client.EndpointURL().JoinPath(s.Bucket).String() + "/"
+	if !strings.HasPrefix(raw, prefix) {
+		return "", false
+	}
+	key, err := url.PathUnescape(strings.TrimPrefix(raw, prefix))
+	if err != nil || key == "" {
+		return "", false
+	}
+	return key, true
+}
+
+// PublicReadable reports whether the bucket policy lets anonymous users read
+// objects, which would expose every customer's photos.
+func (s *S3) PublicReadable(ctx context.Context) (bool, error) {
+	raw, err := s.Client.GetBucketPolicy(ctx, s.Bucket)
+	if err != nil || raw == "" {
+		return false, err
+	}
+	var policy struct {
+		Statement []struct {
+			Effect    string
+			Principal json.RawMessage
+			Action    json.RawMessage
+		}
+	}
+	if err := json.Unmarshal([]byte(raw), &policy); err != nil {
+		return false, err
+	}
+	for _, st := range policy.Statement {
+		anyone := strings.Contains(string(st.Principal), `"*"`)
+		reads := strings.Contains(string(st.Action), "s3:GetObject") || strings.Contains(string(st.Action), `"s3:*"`) || strings.Contains(string(st.Action), `"*"`)
+		if st.Effect == "Allow" && anyone && reads {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *S3) Put(ctx context.Context, objectName string, r io.Reader, size int64, contentType string) error {
+	_, err := s.Client.PutObject(ctx, s.Bucket, objectName, r, size, minio.PutObjectOptions{ContentType: contentType})
+	return err
+}
@@ -0,0 +1,40 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+// ErrNotFound is returned by Stat and Get when the object does not exist.
+var ErrNotFound = errors.New("object not found")
+
+// Store is an object store holding uploads and generated images. Clients never
+// talk to it directly except through the presigned URLs it hands out.
+type Store interface {
+	PresignPut(ctx context.Context, objectName string, contentType string, expire time.Duration) (string, error)
+	PresignPost(ctx context.Context, objectName string, contentType string, maxBytes int64, expire time.Duration) (string, map[string]string, error)
+	PresignGet(ctx context.Context, objectName string, downloadName string, expire time.Duration) (string, error)
+	ViewURL(ctx context.Context, objectName string) string
+	KeyFromURL(raw string) (string, bool)
+
+	Stat(ctx context.Context, objectName string) (*ObjectInfo, error)
+	Get(ctx context.Context, objectName string) (io.ReadCloser, error)
+	Put(ctx context.Context, objectName string, r io.Reader, size int64, contentType string) error
+	Delete(ctx context.Context, objectName string) error
+	Copy(ctx context.Context, src, dst string) error
+	List(ctx context.Context, prefix string) ([]ObjectInfo, error)
+
+	// Multipart uploads, for files too big to send in one request
+	NewMultipart(ctx context.Context, objectName string, contentType string) (uploadID string, err error)
+	PresignPart(ctx context.Context, objectName string, uploadID string, part int, expire time.Duration) (string, error)
+	ListParts(ctx context.Context, objectName string, uploadID string) ([]Part, error)
+	CompleteMultipart(ctx context.Context, objectName string, uploadID string, parts []Part) error
+	AbortMultipart(ctx context.Context, objectName string, uploadID string) error
+}
+
+var (
+	_ Store = (*S3)(nil)
+	_ Store = (*Local)(nil)
+)
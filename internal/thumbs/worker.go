@@ -0,0 +1,167 @@
+package thumbs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/olamideolayemi/framelane-api/internal/imaging"
+	"github.com/olamideolayemi/framelane-api/internal/models"
+	"github.com/olamideolayemi/framelane-api/internal/storage"
+)
+
+// Variant is one generated size, stored as <original>_<Name>.jpg
+type Variant struct {
+	Name    string
+	MaxSide int
+}
+
+var Variants = []Variant{
+	{Name: "thumb", MaxSide: 256},
+	{Name: "medium", MaxSide: 800},
+	{Name: "preview", MaxSide: 1600},
+}
+
+// sweepEvery is how often the worker looks for ready assets that were missed,
+// e.g. because the server restarted with jobs still queued.
+const sweepEvery = 5 * time.Minute
+
+// Failed assets are retried after retryAfter, up to maxAttempts tries in all.
+const (
+	retryAfter  = time.Hour
+	maxAttempts = 3
+)
+
+// Worker generates thumbnails for ready assets in the background.
+type Worker struct {
+	DB        *gorm.DB
+	Store     storage.Store
+	MaxPixels int // larger originals are not decoded; 0 means no limit
+
+	queue chan uuid.UUID
+}
+
+func NewWorker(db *gorm.DB, store storage.Store) *Worker {
+	return &Worker{DB: db, Store: store, queue: make(chan uuid.UUID, 256)}
+}
+
+// Enqueue schedules an asset. A full queue is fine: the sweep picks it up later.
+func (w *Worker) Enqueue(id uuid.UUID) {
+	if w == nil {
+		return
+	}
+	select {
+	case w.queue <- id:
+	default:
+	}
+}
+
+// Run processes jobs with n goroutines until ctx is done.
+func (w *Worker) Run(ctx context.Context, n int) {
+	for i := 0; i < n; i++ {
+		go func() {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case id := <-w.queue:
+					w.process(ctx, id)
+				}
+			}
+		}()
+	}
+
+	t := time.NewTicker(sweepEvery)
+	defer t.Stop()
+	for {
+		w.sweep()
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+func (w *Worker) sweep() {
+	var ids []uuid.UUID
+	err := w.DB.Model(&models.Asset{}).
+		Where("status = ? AND (thumb_status = '' OR thumb_status IS NULL OR (thumb_status = ? AND updated_at < ?) OR (thumb_status = ? AND thumb_attempts < ? AND updated_at < ?))",
+			models.AssetReady, models.ThumbsPending, time.Now().Add(-sweepEvery),
+			models.ThumbsFailed, maxAttempts, time.Now().Add(-retryAfter)).
+		Limit(100).Pluck("id", &ids).Error
+	if err != nil {
+		log.Printf("thumbs: sweep failed: %v", err)
+		return
+	}
+	for _, id := range ids {
+		w.Enqueue(id)
+	}
+}
+
+// VariantKey returns where a variant of objectKey is stored.
+func VariantKey(objectKey, variant string) string {
+	ext := path.Ext(objectKey)
+	return strings.TrimSuffix(objectKey, ext) + "_" + variant + ".jpg"
+}
+
+func (w *Worker) process(ctx context.Context, id uuid.UUID) {
+	var a models.Asset
+	if err := w.DB.First(&a, "id = ?", id).Error; err != nil || a.Status != models.AssetReady || a.ThumbStatus == models.ThumbsDone {
+		return
+	}
+	w.DB.Model(&a).Updates(map[string]any{"thumb_status": models.ThumbsPending, "thumb_attempts": gorm.Expr("thumb_attempts + 1")})
+
+	if err := w.generate(ctx, &a); err != nil {
+		log.Printf("thumbs: asset %s: %v", a.ID, err)
+		failed := map[string]any{"thumb_status": models.ThumbsFailed}
+		if errors.Is(err, imaging.ErrTooManyPixels) {
+			failed["thumb_attempts"] = maxAttempts // retrying won't help
+		}
+		w.DB.Model(&a).Updates(failed)
+		return
+	}
+	w.DB.Model(&a).Updates(map[string]any{
+		"thumb_status": models.ThumbsDone,
+		"thumb_key":    VariantKey(a.ObjectKey, "thumb"),
+		"medium_key":   VariantKey(a.ObjectKey, "medium"),
+		"preview_key":  VariantKey(a.ObjectKey, "preview"),
+	})
+}
+
+func (w *Worker) generate(ctx context.Context, a *models.Asset) error {
+	if err := imaging.CheckPixels(a.Width, a.Height, w.MaxPixels); err != nil {
+		return err
+	}
+	obj, err := w.Store.Get(ctx, a.ObjectKey)
+	if err != nil {
+		return err
+	}
+	img, err := imaging.Decode(obj, w.MaxPixels)
+	obj.Close()
+	if err != nil {
+		return err
+	}
+
+	// Largest first, each smaller size scaled from the previous one
+	src := img
+	for i := len(Variants) - 1; i >= 0; i-- {
+		v := Variants[i]
+		src = imaging.Fit(src, v.MaxSide)
+		out, err := imaging.EncodeJPEG(src, 85)
+		if err != nil {
+			return err
+		}
+		if err := w.Store.Put(ctx, VariantKey(a.ObjectKey, v.Name), bytes.NewReader(out), int64(len(out)), "image/jpeg"); err != nil {
+			return err
+		}
+	}
+	return nil
+}